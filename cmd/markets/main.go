@@ -5,10 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"markets-sdk"
+	"markets-sdk/pkg/config"
+	"markets-sdk/pkg/domain"
 	"markets-sdk/pkg/providers/coingecko"
 	"markets-sdk/pkg/providers/yahoo"
 )
@@ -26,26 +29,37 @@ const (
 
 func main() {
 	// Defines flags
-	providerFlag := flag.String("provider", "", "Provider to use: 'crypto' or 'stock'")
-	symbolFlag := flag.String("symbol", "", "Symbol to fetch (e.g., 'bitcoin', 'AAPL')")
+	providerFlag := flag.String("provider", "", "Provider to use: 'crypto' or 'stock'; routed automatically when omitted")
+	symbolFlag := flag.String("symbol", "", "Symbol to fetch (e.g., 'bitcoin', 'AAPL'), or an instrument such as 'stock:XNAS:AAPL' without -provider")
+	configFlag := flag.String("config", "", "Path to a JSON config file describing the providers")
 	flag.Parse()
 
-	if *providerFlag == "" || *symbolFlag == "" {
+	if *symbolFlag == "" {
 		printUsage()
 		os.Exit(1)
 	}
 
 	// Initialize Client
-	client := markets.NewMarketClient()
-	client.RegisterProvider("crypto", coingecko.NewProvider())
-	client.RegisterProvider("stock", yahoo.NewProvider())
+	client, err := newClient(*configFlag)
+	if err != nil {
+		fmt.Printf("%sError: %v%s\n", ColorRed, err, ColorReset)
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	fmt.Printf("%sFetching data for %s...%s\n", ColorCyan, *symbolFlag, ColorReset)
 
-	quote, err := client.GetQuote(ctx, *providerFlag, *symbolFlag)
+	var quote *markets.Quote
+	if *providerFlag != "" {
+		quote, err = client.GetQuote(ctx, *providerFlag, *symbolFlag)
+	} else {
+		var inst domain.Instrument
+		if inst, err = domain.ParseInstrument(*symbolFlag); err == nil {
+			quote, err = client.Quote(ctx, inst)
+		}
+	}
 	if err != nil {
 		fmt.Printf("%sError: %v%s\n", ColorRed, err, ColorReset)
 		os.Exit(1)
@@ -54,13 +68,43 @@ func main() {
 	printStylish(quote)
 }
 
+// newClient builds the client from the config file, or registers the built-in
+// providers as "crypto" and "stock" when no config is given
+func newClient(configPath string) (*markets.MarketClient, error) {
+	if configPath != "" {
+		return config.LoadClient(configPath)
+	}
+
+	client := markets.NewMarketClient()
+	client.RegisterProvider("crypto", coingecko.NewProvider(coingecko.WithRegistry(catalogCache()...)))
+	client.RegisterProvider("stock", yahoo.NewProvider())
+	return client, nil
+}
+
+// catalogCache keeps the CoinGecko symbol catalog in the user cache directory, so each
+// run does not download it again. Without a usable directory the catalog stays in memory.
+func catalogCache() []coingecko.RegistryOption {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	dir = filepath.Join(dir, "markets")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil
+	}
+	return []coingecko.RegistryOption{coingecko.WithCatalogCache(filepath.Join(dir, "coingecko-coins.json"))}
+}
+
 func printUsage() {
 	fmt.Printf("%sMarkets CLI%s\n", ColorBold, ColorReset)
 	fmt.Println("Usage:")
-	fmt.Println("  markets -provider <crypto|stock> -symbol <name>")
+	fmt.Println("  markets [-config <file>] -provider <name> -symbol <name>")
+	fmt.Println("  markets [-config <file>] -symbol <type>:[<exchange>:]<symbol>[/<currency>]")
 	fmt.Println("\nExamples:")
 	fmt.Println("  markets -provider crypto -symbol bitcoin")
 	fmt.Println("  markets -provider stock -symbol AAPL")
+	fmt.Println("  markets -symbol crypto:BTC/USD")
+	fmt.Println("  markets -config examples/config.json -provider crypto -symbol bitcoin")
 }
 
 func printStylish(q *markets.Quote) {
@@ -68,14 +112,14 @@ func printStylish(q *markets.Quote) {
 	fmt.Printf("%s%s (%s)%s\n", ColorBold, strings.ToUpper(q.Symbol), strings.ToUpper(q.Source), ColorReset)
 	fmt.Println(strings.Repeat("-", 30))
 
-	fmt.Printf("Price:      %s$%.2f%s\n", ColorBlue, q.Price, ColorReset)
+	fmt.Printf("Price:      %s$%s%s\n", ColorBlue, q.Price.Round(2, domain.RoundHalfEven), ColorReset)
 
 	// Colorize change
 	changeColor := ColorGreen
-	if q.Change24h < 0 {
+	if q.Change24h.Sign() < 0 {
 		changeColor = ColorRed
 	}
-	fmt.Printf("Change 24h: %s$%.2f%s\n", changeColor, q.Change24h, ColorReset)
+	fmt.Printf("Change 24h: %s$%s%s\n", changeColor, q.Change24h.Round(2, domain.RoundHalfEven), ColorReset)
 
 	fmt.Printf("Updated:    %s\n", q.LastUpdated.Format(time.Kitchen))
 	fmt.Println(strings.Repeat("-", 30))
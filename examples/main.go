@@ -46,8 +46,8 @@ func main() {
 
 func printQuote(q *markets.Quote) {
 	fmt.Printf("Symbol: %s\n", q.Symbol)
-	fmt.Printf("Price: %.2f\n", q.Price)
-	fmt.Printf("Change: %.2f\n", q.Change24h)
+	fmt.Printf("Price: %s\n", q.Price)
+	fmt.Printf("Change: %s\n", q.Change24h)
 	fmt.Printf("Source: %s\n", q.Source)
 	fmt.Printf("Updated: %s\n", q.LastUpdated.Format(time.RFC3339))
 }
@@ -3,36 +3,213 @@ package markets
 import (
 	"context"
 	"fmt"
+	"markets-sdk/pkg/decorators"
 	"markets-sdk/pkg/domain"
 	"markets-sdk/pkg/ports"
+	"sync"
+	"time"
 )
 
 // Ensure backward compatibility and ease of use
 type Quote = domain.Quote
 type AssetType = domain.AssetType
+type Candle = domain.Candle
+type Interval = domain.Interval
+type BatchError = domain.BatchError
+type Instrument = domain.Instrument
+
+// defaultBatchConcurrency bounds the fan-out used by GetQuotes for providers without native batching
+const defaultBatchConcurrency = 8
 
 // MarketClient is the main entry point that can manage multiple providers
 type MarketClient struct {
-	providers map[string]ports.Provider
+	providers        map[string]ports.Provider
+	translators      map[string]ports.SymbolTranslator
+	order            []string // provider names in registration order
+	routes           []Route
+	batchConcurrency int
+	reconnectBase    time.Duration
+	reconnectMax     time.Duration
+
+	streamMu sync.Mutex
+	streams  map[streamKey]*stream
+}
+
+// ClientOption configures a MarketClient
+type ClientOption func(*MarketClient)
+
+// WithBatchConcurrency sets how many GetQuote calls GetQuotes runs in parallel
+// for providers that do not batch natively
+func WithBatchConcurrency(n int) ClientOption {
+	return func(c *MarketClient) {
+		if n > 0 {
+			c.batchConcurrency = n
+		}
+	}
 }
 
 // NewMarketClient creates a new client
-func NewMarketClient() *MarketClient {
-	return &MarketClient{
-		providers: make(map[string]ports.Provider),
+func NewMarketClient(opts ...ClientOption) *MarketClient {
+	c := &MarketClient{
+		providers:        make(map[string]ports.Provider),
+		translators:      make(map[string]ports.SymbolTranslator),
+		batchConcurrency: defaultBatchConcurrency,
+		reconnectBase:    defaultReconnectBackoff,
+		reconnectMax:     defaultMaxReconnect,
+		streams:          make(map[streamKey]*stream),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// RegisterProvider registers a provider with a specific name
-func (c *MarketClient) RegisterProvider(name string, p ports.Provider) {
+// RegisterProvider registers a provider with a specific name, optionally wrapped in
+// middleware. The first middleware is the outermost, see decorators.Chain and
+// decorators.Builder.
+func (c *MarketClient) RegisterProvider(name string, p ports.Provider, mw ...decorators.Middleware) {
+	// Translation is a pure function of the provider, so it is looked up before the
+	// middleware hides it
+	if t, ok := p.(ports.SymbolTranslator); ok {
+		c.translators[name] = t
+	} else {
+		delete(c.translators, name)
+	}
+	if _, ok := c.providers[name]; !ok {
+		c.order = append(c.order, name)
+	}
+	if len(mw) > 0 {
+		p = decorators.Chain(mw...)(p)
+	}
 	c.providers[name] = p
 }
 
+// ProviderInfo describes a registered provider
+type ProviderInfo struct {
+	Name         string
+	Capabilities ports.Capabilities
+}
+
+// Providers lists the registered providers in registration order. Capabilities
+// are those reachable through the provider's middleware.
+func (c *MarketClient) Providers() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(c.order))
+	for _, name := range c.order {
+		infos = append(infos, ProviderInfo{Name: name, Capabilities: ports.CapabilitiesOf(c.providers[name])})
+	}
+	return infos
+}
+
+// NativeSymbol translates inst into the symbol understood by a provider. A code in
+// inst.Codes for the provider wins, then the provider's ports.SymbolTranslator; other
+// providers receive inst.Base unchanged.
+func (c *MarketClient) NativeSymbol(providerName string, inst domain.Instrument) (string, error) {
+	if _, ok := c.providers[providerName]; !ok {
+		return "", fmt.Errorf("%w: %s", domain.ErrProviderNotFound, providerName)
+	}
+	if code, ok := inst.Codes[providerName]; ok {
+		return code, nil
+	}
+	if t, ok := c.translators[providerName]; ok {
+		return t.NativeSymbol(inst)
+	}
+	return inst.Base, nil
+}
+
 // GetQuote fetches a quote from a specific provider
 func (c *MarketClient) GetQuote(ctx context.Context, providerName string, symbol string) (*domain.Quote, error) {
 	p, ok := c.providers[providerName]
 	if !ok {
-		return nil, fmt.Errorf("provider %s not found", providerName)
+		return nil, fmt.Errorf("%w: %s", domain.ErrProviderNotFound, providerName)
 	}
 	return p.GetQuote(ctx, symbol)
 }
+
+// GetQuotes fetches quotes for several symbols from a specific provider.
+// Providers that batch natively, see ports.AsBatchProvider, are called once, others are queried
+// with bounded concurrency. Symbols that fail are reported through a *domain.BatchError
+// returned alongside the quotes that succeeded.
+func (c *MarketClient) GetQuotes(ctx context.Context, providerName string, symbols []string) (map[string]*domain.Quote, error) {
+	p, ok := c.providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrProviderNotFound, providerName)
+	}
+
+	symbols = dedupe(symbols)
+	if bp, ok := ports.AsBatchProvider(p); ok {
+		return bp.GetQuotes(ctx, symbols)
+	}
+	return c.fanOut(ctx, p, symbols)
+}
+
+// fanOut calls GetQuote for each symbol using at most batchConcurrency goroutines
+func (c *MarketClient) fanOut(ctx context.Context, p ports.Provider, symbols []string) (map[string]*domain.Quote, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		quotes = make(map[string]*domain.Quote, len(symbols))
+		failed = make(map[string]error)
+		sem    = make(chan struct{}, c.batchConcurrency)
+	)
+
+	for _, symbol := range symbols {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			failed[symbol] = ctx.Err()
+			mu.Unlock()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			q, err := p.GetQuote(ctx, symbol)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[symbol] = err
+				return
+			}
+			quotes[symbol] = q
+		}(symbol)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+// dedupe removes repeated symbols while preserving order
+func dedupe(symbols []string) []string {
+	seen := make(map[string]struct{}, len(symbols))
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
+
+// GetHistory fetches historical candles from a specific provider.
+// The provider, through its middleware, must implement ports.HistoricalProvider.
+func (c *MarketClient) GetHistory(ctx context.Context, providerName string, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	p, ok := c.providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrProviderNotFound, providerName)
+	}
+	hp, ok := ports.AsHistoricalProvider(p)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support historical data", domain.ErrNotSupported, providerName)
+	}
+	return hp.GetHistory(ctx, symbol, interval, from, to)
+}
@@ -3,10 +3,13 @@ package markets_test
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"markets-sdk"
+	"markets-sdk/pkg/decorators"
 	"markets-sdk/pkg/domain"
 	// Use mock provider from internal tests manually or define a simple one here
 	// Since markets_test is external, we need a local mock
@@ -23,7 +26,7 @@ func (m *mockProvider) GetQuote(ctx context.Context, symbol string) (*domain.Quo
 	}
 	return &domain.Quote{
 		Symbol:      symbol,
-		Price:       m.price,
+		Price:       domain.NewDecimalFromFloat(m.price),
 		LastUpdated: time.Now(),
 		Source:      "mock",
 	}, nil
@@ -41,8 +44,8 @@ func TestMarketClient(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if q.Price != 150.0 {
-		t.Errorf("expected price 150.0, got %f", q.Price)
+	if !q.Price.Equal(domain.NewDecimalFromInt(150)) {
+		t.Errorf("expected price 150.0, got %s", q.Price)
 	}
 	if q.Source != "mock" {
 		t.Errorf("expected source 'mock', got %s", q.Source)
@@ -53,6 +56,9 @@ func TestMarketClient(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for missing provider")
 	}
+	if !errors.Is(err, domain.ErrProviderNotFound) {
+		t.Errorf("expected ErrProviderNotFound, got %v", err)
+	}
 
 	// 4. Test Provider Error
 	failMock := &mockProvider{err: errors.New("network error")}
@@ -62,3 +68,209 @@ func TestMarketClient(t *testing.T) {
 		t.Error("expected error from provider")
 	}
 }
+
+type mockHistoricalProvider struct {
+	mockProvider
+	candles []domain.Candle
+}
+
+func (m *mockHistoricalProvider) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return m.candles, nil
+}
+
+func TestMarketClientGetHistory(t *testing.T) {
+	client := markets.NewMarketClient()
+	now := time.Now()
+
+	hist := &mockHistoricalProvider{
+		candles: []domain.Candle{
+			{Symbol: "ABC", Interval: domain.Interval1h, Start: now.Add(-time.Hour), Open: domain.NewDecimalFromInt(1), High: domain.NewDecimalFromInt(2), Low: domain.NewDecimalFromInt(1), Close: domain.NewDecimalFromInt(2)},
+		},
+	}
+	client.RegisterProvider("hist", hist)
+	client.RegisterProvider("plain", &mockProvider{price: 1})
+
+	candles, err := client.GetHistory(context.Background(), "hist", "ABC", domain.Interval1h, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candles) != 1 || !candles[0].Close.Equal(domain.NewDecimalFromInt(2)) {
+		t.Errorf("unexpected candles: %+v", candles)
+	}
+
+	// Provider without history support
+	if _, err := client.GetHistory(context.Background(), "plain", "ABC", domain.Interval1h, now.Add(-time.Hour), now); !errors.Is(err, domain.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+
+	// Provider Not Found
+	if _, err := client.GetHistory(context.Background(), "missing", "ABC", domain.Interval1h, now.Add(-time.Hour), now); err == nil {
+		t.Error("expected error for missing provider")
+	}
+}
+
+type mockBatchProvider struct {
+	mockProvider
+	calls int
+}
+
+func (m *mockBatchProvider) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	m.calls++
+	quotes := make(map[string]*domain.Quote, len(symbols))
+	for _, s := range symbols {
+		quotes[s] = &domain.Quote{Symbol: s, Price: domain.NewDecimalFromFloat(m.price), Source: "mock-batch"}
+	}
+	return quotes, nil
+}
+
+func TestMarketClientGetQuotes(t *testing.T) {
+	ctx := context.Background()
+	client := markets.NewMarketClient(markets.WithBatchConcurrency(2))
+
+	// 1. Native batch path
+	batch := &mockBatchProvider{mockProvider: mockProvider{price: 10}}
+	client.RegisterProvider("batch", batch)
+
+	quotes, err := client.GetQuotes(ctx, "batch", []string{"A", "B", "A"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.calls != 1 {
+		t.Errorf("expected 1 batch call, got %d", batch.calls)
+	}
+	if len(quotes) != 2 || quotes["B"].Source != "mock-batch" {
+		t.Errorf("unexpected quotes: %+v", quotes)
+	}
+
+	// 2. Fan-out fallback with a per-symbol failure
+	var calls int32
+	client.RegisterProvider("single", providerFunc(func(ctx context.Context, symbol string) (*domain.Quote, error) {
+		atomic.AddInt32(&calls, 1)
+		if symbol == "BAD" {
+			return nil, errors.New("not found")
+		}
+		return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(1)}, nil
+	}))
+
+	quotes, err = client.GetQuotes(ctx, "single", []string{"A", "BAD", "C"})
+	var batchErr *markets.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	if _, ok := batchErr.Errors["BAD"]; !ok || len(batchErr.Errors) != 1 {
+		t.Errorf("unexpected batch errors: %v", batchErr.Errors)
+	}
+	if len(quotes) != 2 || quotes["A"] == nil || quotes["C"] == nil {
+		t.Errorf("expected partial result, got %+v", quotes)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+// providerFunc adapts a function to the Provider interface
+type providerFunc func(ctx context.Context, symbol string) (*domain.Quote, error)
+
+func (f providerFunc) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return f(ctx, symbol)
+}
+
+func TestMarketClientRegisterProviderMiddleware(t *testing.T) {
+	client := markets.NewMarketClient()
+
+	calls := 0
+	failing := providerFunc(func(ctx context.Context, symbol string) (*domain.Quote, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("flaky")
+		}
+		return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(1)}, nil
+	})
+	client.RegisterProvider("flaky", failing, decorators.NewBuilder().Retry(3, time.Millisecond).Middleware())
+
+	if _, err := client.GetQuote(context.Background(), "flaky", "ABC"); err != nil {
+		t.Fatalf("expected retries from middleware to recover, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+// translatingProvider maps instruments to lower-case native symbols
+type translatingProvider struct {
+	mockProvider
+}
+
+func (p *translatingProvider) NativeSymbol(inst domain.Instrument) (string, error) {
+	return strings.ToLower(inst.Base), nil
+}
+
+func TestMarketClientNativeSymbol(t *testing.T) {
+	client := markets.NewMarketClient()
+	client.RegisterProvider("translating", &translatingProvider{}, decorators.NewBuilder().Retry(1, time.Millisecond).Middleware())
+	client.RegisterProvider("plain", &mockProvider{})
+
+	inst := domain.MustParseInstrument("crypto:BTC/USD")
+
+	// 1. The provider's translation is found behind its middleware
+	if got, err := client.NativeSymbol("translating", inst); err != nil || got != "btc" {
+		t.Errorf("expected btc, got %q, %v", got, err)
+	}
+	// 2. Providers without translation receive the base symbol
+	if got, err := client.NativeSymbol("plain", inst); err != nil || got != "BTC" {
+		t.Errorf("expected BTC, got %q, %v", got, err)
+	}
+	// 3. Explicit codes win
+	inst.Codes = map[string]string{"translating": "xbt"}
+	if got, err := client.NativeSymbol("translating", inst); err != nil || got != "xbt" {
+		t.Errorf("expected xbt, got %q, %v", got, err)
+	}
+	if _, err := client.NativeSymbol("missing", inst); !errors.Is(err, domain.ErrProviderNotFound) {
+		t.Errorf("expected ErrProviderNotFound, got %v", err)
+	}
+}
+
+func TestMarketClientProviders(t *testing.T) {
+	client := markets.NewMarketClient()
+	client.RegisterProvider("history", &mockHistoricalProvider{})
+	client.RegisterProvider("batch", &mockBatchProvider{}, decorators.NewBuilder().Retry(1, time.Millisecond).Middleware())
+
+	infos := client.Providers()
+	if len(infos) != 2 || infos[0].Name != "history" || infos[1].Name != "batch" {
+		t.Fatalf("expected providers in registration order, got %+v", infos)
+	}
+	// Capabilities are derived from the optional interfaces, including behind middleware
+	if !infos[0].Capabilities.History {
+		t.Error("expected history capability")
+	}
+	if !infos[1].Capabilities.Batch {
+		t.Error("expected batch support through the retry middleware")
+	}
+}
+
+func TestMarketClientMiddlewareForwarding(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	production, err := decorators.Preset("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := markets.NewMarketClient()
+	hist := &mockHistoricalProvider{candles: []domain.Candle{{Symbol: "ABC", Close: domain.NewDecimalFromInt(2)}}}
+	batch := &mockBatchProvider{mockProvider: mockProvider{price: 10}}
+	client.RegisterProvider("hist", hist, production.Middleware())
+	client.RegisterProvider("batch", batch, production.Middleware())
+
+	candles, err := client.GetHistory(ctx, "hist", "ABC", domain.Interval1h, now.Add(-time.Hour), now)
+	if err != nil || len(candles) != 1 {
+		t.Errorf("expected history through the production preset, got %v, %v", candles, err)
+	}
+	quotes, err := client.GetQuotes(ctx, "batch", []string{"A", "B"})
+	if err != nil || len(quotes) != 2 || batch.calls != 1 {
+		t.Errorf("expected one native batch through the production preset, got %d call(s), %v", batch.calls, err)
+	}
+	if _, err := client.GetHistory(ctx, "batch", "A", domain.Interval1h, now.Add(-time.Hour), now); !errors.Is(err, domain.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported for a provider without history, got %v", err)
+	}
+}
@@ -0,0 +1,641 @@
+// Package config builds a fully wired MarketClient from a declarative JSON file
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"markets-sdk"
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+	"markets-sdk/pkg/providers/coingecko"
+	"markets-sdk/pkg/providers/yahoo"
+)
+
+// Config describes the providers of a MarketClient and their decorator stacks
+type Config struct {
+	// BatchConcurrency bounds GetQuotes fan-out for providers without native batching
+	BatchConcurrency int `json:"batch_concurrency,omitempty"`
+	// RateLimiters declares named limiters that several providers can share
+	RateLimiters map[string]RateLimitConfig `json:"rate_limiters,omitempty"`
+	// Providers maps the name used with MarketClient to its configuration
+	Providers map[string]ProviderConfig `json:"providers"`
+	// Routes select providers for MarketClient.Quote
+	Routes []RouteConfig `json:"routes,omitempty"`
+}
+
+// RouteConfig mirrors markets.Route. AssetType is "stock" or "crypto"; empty fields
+// match anything.
+type RouteConfig struct {
+	AssetType string `json:"asset_type,omitempty"`
+	Exchange  string `json:"exchange,omitempty"`
+	Provider  string `json:"provider"`
+	Priority  int    `json:"priority,omitempty"`
+}
+
+// ProviderConfig describes a single provider. String fields may reference
+// environment variables as ${NAME}.
+type ProviderConfig struct {
+	Type      string `json:"type"`
+	BaseURL   string `json:"base_url,omitempty"`
+	APIKey    string `json:"api_key,omitempty"`
+	Plan      string `json:"plan,omitempty"` // CoinGecko plan of APIKey: "demo" or "pro"
+	UserAgent string `json:"user_agent,omitempty"`
+	// HTTPTimeout bounds each HTTP request of a built-in provider
+	HTTPTimeout Duration `json:"http_timeout,omitempty"`
+	// Symbols enables CoinGecko's ticker, name and contract resolution
+	Symbols    *SymbolsConfig   `json:"symbols,omitempty"`
+	Preset     string           `json:"preset,omitempty"`
+	Middleware MiddlewareConfig `json:"middleware,omitempty"`
+
+	limiter *decorators.Limiter
+}
+
+// Limiter returns the limiter Build throttles the provider with, or nil. Factories
+// should make requests the provider sends on its own wait on it too.
+func (pc ProviderConfig) Limiter() *decorators.Limiter {
+	return pc.limiter
+}
+
+// SymbolsConfig configures the CoinGecko symbol registry
+type SymbolsConfig struct {
+	CacheFile      string            `json:"cache_file,omitempty"`
+	CacheTTL       Duration          `json:"cache_ttl,omitempty"`
+	Disambiguation string            `json:"disambiguation,omitempty"` // "market_cap" (default) or "strict"
+	Aliases        map[string]string `json:"aliases,omitempty"`
+}
+
+// MiddlewareConfig lists the decorators to apply. They are always applied in the
+// order of decorators.Builder; settings here override the preset.
+type MiddlewareConfig struct {
+	Logging        bool                  `json:"logging,omitempty"`
+	Cache          *CacheConfig          `json:"cache,omitempty"`
+	Coalesce       bool                  `json:"coalesce,omitempty"`
+	Retry          *RetryConfig          `json:"retry,omitempty"`
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	Bulkhead       *BulkheadConfig       `json:"bulkhead,omitempty"`
+	RateLimit      *RateLimitConfig      `json:"rate_limit,omitempty"`
+	Timeout        Duration              `json:"timeout,omitempty"`
+}
+
+type CacheConfig struct {
+	Size        int      `json:"size"`
+	TTL         Duration `json:"ttl"`
+	StaleTTL    Duration `json:"stale_ttl,omitempty"`
+	NegativeTTL Duration `json:"negative_ttl,omitempty"`
+}
+
+type RetryConfig struct {
+	MaxRetries int      `json:"max_retries"`
+	BaseDelay  Duration `json:"base_delay"`
+	MaxDelay   Duration `json:"max_delay,omitempty"`
+	Jitter     string   `json:"jitter,omitempty"` // "none", "full" or "decorrelated"
+}
+
+type CircuitBreakerConfig struct {
+	FailureThreshold int      `json:"failure_threshold"`
+	ResetTimeout     Duration `json:"reset_timeout"`
+	WindowSize       int      `json:"window_size,omitempty"`
+	Window           Duration `json:"window,omitempty"`
+	MinCalls         int      `json:"min_calls,omitempty"`
+	FailureRate      float64  `json:"failure_rate,omitempty"`
+	HalfOpenProbes   int      `json:"half_open_probes,omitempty"`
+	PerSymbol        bool     `json:"per_symbol,omitempty"`
+}
+
+type BulkheadConfig struct {
+	MaxConcurrent int      `json:"max_concurrent"`
+	Queue         int      `json:"queue,omitempty"`
+	QueueTimeout  Duration `json:"queue_timeout,omitempty"`
+}
+
+// RateLimitConfig allows Requests per Per (default one second) with bursts of Burst.
+// Inside a provider, Shared refers to a limiter declared in Config.RateLimiters instead,
+// and Disabled turns off rate limiting, including CoinGecko's default plan limit.
+type RateLimitConfig struct {
+	Requests float64  `json:"requests,omitempty"`
+	Per      Duration `json:"per,omitempty"`
+	Burst    int      `json:"burst,omitempty"`
+	Adaptive bool     `json:"adaptive,omitempty"`
+	Shared   string   `json:"shared,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// Duration is a time.Duration written as a Go duration string such as "250ms" or "1m"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Factory creates a provider of a given type from its configuration
+type Factory func(cfg ProviderConfig) (ports.Provider, error)
+
+var factories = map[string]Factory{
+	"coingecko": func(cfg ProviderConfig) (ports.Provider, error) {
+		var opts []coingecko.Option
+		if cfg.APIKey != "" {
+			if cfg.Plan == "" {
+				return nil, fmt.Errorf("api_key requires plan \"demo\" or \"pro\"")
+			}
+			plan, err := coingecko.ParsePlan(cfg.Plan)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, coingecko.WithAPIKey(cfg.APIKey, plan))
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, coingecko.WithBaseURL(cfg.BaseURL))
+		}
+		if cfg.UserAgent != "" {
+			opts = append(opts, coingecko.WithUserAgent(cfg.UserAgent))
+		}
+		if cfg.HTTPTimeout > 0 {
+			opts = append(opts, coingecko.WithTimeout(time.Duration(cfg.HTTPTimeout)))
+		}
+		if sc := cfg.Symbols; sc != nil {
+			var ropts []coingecko.RegistryOption
+			if sc.CacheFile != "" {
+				ropts = append(ropts, coingecko.WithCatalogCache(sc.CacheFile))
+			}
+			if sc.CacheTTL > 0 {
+				ropts = append(ropts, coingecko.WithCatalogTTL(time.Duration(sc.CacheTTL)))
+			}
+			switch sc.Disambiguation {
+			case "", "market_cap":
+			case "strict":
+				ropts = append(ropts, coingecko.WithDisambiguation(coingecko.Strict))
+			default:
+				return nil, fmt.Errorf("unknown disambiguation %q", sc.Disambiguation)
+			}
+			if len(sc.Aliases) > 0 {
+				ropts = append(ropts, coingecko.WithAliases(sc.Aliases))
+			}
+			if l := cfg.Limiter(); l != nil {
+				ropts = append(ropts, coingecko.WithCatalogLimiter(func(ctx context.Context) error {
+					return l.Wait(ctx, 1)
+				}))
+			}
+			opts = append(opts, coingecko.WithRegistry(ropts...))
+		}
+		return coingecko.NewProvider(opts...), nil
+	},
+	"yahoo": func(cfg ProviderConfig) (ports.Provider, error) {
+		if err := unsupported(cfg, "api_key", cfg.APIKey); err != nil {
+			return nil, err
+		}
+		if err := unsupported(cfg, "plan", cfg.Plan); err != nil {
+			return nil, err
+		}
+		if cfg.Symbols != nil {
+			return nil, fmt.Errorf("provider type %s does not support symbols", cfg.Type)
+		}
+		var opts []yahoo.Option
+		if cfg.BaseURL != "" {
+			opts = append(opts, yahoo.WithBaseURL(cfg.BaseURL))
+		}
+		if cfg.UserAgent != "" {
+			opts = append(opts, yahoo.WithUserAgent(cfg.UserAgent))
+		}
+		if cfg.HTTPTimeout > 0 {
+			opts = append(opts, yahoo.WithTimeout(time.Duration(cfg.HTTPTimeout)))
+		}
+		return yahoo.NewProvider(opts...), nil
+	},
+}
+
+func unsupported(cfg ProviderConfig, field, value string) error {
+	if value != "" {
+		return fmt.Errorf("provider type %s does not support %s", cfg.Type, field)
+	}
+	return nil
+}
+
+// RegisterProviderType makes a custom provider type available to configuration files
+func RegisterProviderType(name string, factory Factory) {
+	factories[name] = factory
+}
+
+// Load reads a configuration file
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse decodes a configuration, rejecting unknown fields so typos are caught early
+func Parse(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if len(cfg.Providers) == 0 {
+		return nil, fmt.Errorf("invalid config: no providers")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// validate checks the settings that would otherwise build a silently broken client.
+// Errors name the offending field by its JSON path.
+func (cfg *Config) validate() error {
+	names := make([]string, 0, len(cfg.RateLimiters))
+	for name := range cfg.RateLimiters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rl := cfg.RateLimiters[name]
+		if rl.Shared != "" {
+			return fmt.Errorf("rate_limiters.%s.shared: only providers can refer to a shared limiter", name)
+		}
+		if rl.Disabled {
+			return fmt.Errorf("rate_limiters.%s.disabled: only providers can disable rate limiting", name)
+		}
+		if err := rl.validate("rate_limiters." + name); err != nil {
+			return err
+		}
+	}
+
+	names = names[:0]
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := cfg.Providers[name].Middleware.validate("providers." + name + ".middleware"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MiddlewareConfig) validate(path string) error {
+	if c := m.Cache; c != nil {
+		if err := errors.Join(
+			positive(path+".cache.size", c.Size),
+			positive(path+".cache.ttl", c.TTL),
+			notNegative(path+".cache.stale_ttl", c.StaleTTL),
+			notNegative(path+".cache.negative_ttl", c.NegativeTTL),
+		); err != nil {
+			return err
+		}
+	}
+	if r := m.Retry; r != nil {
+		if err := errors.Join(
+			positive(path+".retry.max_retries", r.MaxRetries),
+			positive(path+".retry.base_delay", r.BaseDelay),
+			notNegative(path+".retry.max_delay", r.MaxDelay),
+		); err != nil {
+			return err
+		}
+	}
+	if cb := m.CircuitBreaker; cb != nil {
+		// A zero failure_threshold is valid when the failure rate opens the breaker
+		threshold := notNegative(path+".circuit_breaker.failure_threshold", cb.FailureThreshold)
+		if cb.FailureRate == 0 {
+			threshold = positive(path+".circuit_breaker.failure_threshold", cb.FailureThreshold)
+		}
+		if err := errors.Join(
+			threshold,
+			positive(path+".circuit_breaker.reset_timeout", cb.ResetTimeout),
+			notNegative(path+".circuit_breaker.window_size", cb.WindowSize),
+			notNegative(path+".circuit_breaker.window", cb.Window),
+			notNegative(path+".circuit_breaker.min_calls", cb.MinCalls),
+			notNegative(path+".circuit_breaker.half_open_probes", cb.HalfOpenProbes),
+		); err != nil {
+			return err
+		}
+		if cb.FailureRate < 0 || cb.FailureRate > 1 {
+			return fmt.Errorf("%s.circuit_breaker.failure_rate: must be between 0 and 1, got %v", path, cb.FailureRate)
+		}
+	}
+	if bh := m.Bulkhead; bh != nil {
+		if err := errors.Join(
+			positive(path+".bulkhead.max_concurrent", bh.MaxConcurrent),
+			notNegative(path+".bulkhead.queue", bh.Queue),
+			notNegative(path+".bulkhead.queue_timeout", bh.QueueTimeout),
+		); err != nil {
+			return err
+		}
+	}
+	if rl := m.RateLimit; rl != nil && rl.Shared == "" && !rl.Disabled {
+		if err := rl.validate(path + ".rate_limit"); err != nil {
+			return err
+		}
+	}
+	return notNegative(path+".timeout", m.Timeout)
+}
+
+func (rl RateLimitConfig) validate(path string) error {
+	return errors.Join(
+		positive(path+".requests", rl.Requests),
+		notNegative(path+".per", rl.Per),
+		notNegative(path+".burst", rl.Burst),
+	)
+}
+
+func positive[T int | float64 | Duration](field string, v T) error {
+	if v <= 0 {
+		return fmt.Errorf("%s: must be positive, got %v", field, v)
+	}
+	return nil
+}
+
+func notNegative[T int | Duration](field string, v T) error {
+	if v < 0 {
+		return fmt.Errorf("%s: must not be negative, got %v", field, v)
+	}
+	return nil
+}
+
+// Build creates a MarketClient with every configured provider registered
+func Build(cfg *Config) (*markets.MarketClient, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	var opts []markets.ClientOption
+	if cfg.BatchConcurrency > 0 {
+		opts = append(opts, markets.WithBatchConcurrency(cfg.BatchConcurrency))
+	}
+	client := markets.NewMarketClient(opts...)
+
+	limiters := make(map[string]*decorators.Limiter, len(cfg.RateLimiters))
+	for name, rl := range cfg.RateLimiters {
+		limiters[name] = newLimiter(rl)
+	}
+
+	// Sorted for deterministic error reporting
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pc, err := expandEnv(cfg.Providers[name])
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+
+		factory, ok := factories[pc.Type]
+		if !ok {
+			return nil, fmt.Errorf("provider %s: unknown type %q", name, pc.Type)
+		}
+		if pc.limiter, err = providerLimiter(pc, limiters); err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+		p, err := factory(pc)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+
+		mw, err := middleware(name, pc)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+		client.RegisterProvider(name, p, mw)
+	}
+
+	for i, rc := range cfg.Routes {
+		r, err := route(rc)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+		if _, ok := cfg.Providers[r.Provider]; !ok {
+			return nil, fmt.Errorf("route %d: unknown provider %q", i, r.Provider)
+		}
+		client.AddRoute(r)
+	}
+	return client, nil
+}
+
+func route(rc RouteConfig) (markets.Route, error) {
+	r := markets.Route{Exchange: strings.ToUpper(rc.Exchange), Provider: rc.Provider, Priority: rc.Priority}
+	if rc.AssetType != "" {
+		t, ok := domain.ParseAssetType(rc.AssetType)
+		if !ok {
+			return r, fmt.Errorf("unknown asset type %q", rc.AssetType)
+		}
+		r.AssetType = t
+	}
+	return r, nil
+}
+
+// LoadClient is a shortcut for Load followed by Build
+func LoadClient(path string) (*markets.MarketClient, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return Build(cfg)
+}
+
+func middleware(name string, pc ProviderConfig) (decorators.Middleware, error) {
+	b := decorators.NewBuilder()
+	if pc.Preset != "" {
+		var err error
+		if b, err = decorators.Preset(pc.Preset); err != nil {
+			return nil, err
+		}
+	}
+
+	m := pc.Middleware
+	if m.RateLimit == nil {
+		m.RateLimit = defaultRateLimit(pc)
+	}
+	if m.Logging {
+		b.Logging(slog.Default(), name)
+	}
+	if c := m.Cache; c != nil {
+		var opts []decorators.CacheOption
+		if c.StaleTTL > 0 {
+			opts = append(opts, decorators.WithStaleWhileRevalidate(time.Duration(c.StaleTTL)))
+		}
+		if c.NegativeTTL > 0 {
+			opts = append(opts, decorators.WithNegativeTTL(time.Duration(c.NegativeTTL)))
+		}
+		b.Cache(c.Size, time.Duration(c.TTL), opts...)
+	}
+	if m.Coalesce {
+		b.Coalesce()
+	}
+	if r := m.Retry; r != nil {
+		opts := []decorators.RetryOption{}
+		if r.MaxDelay > 0 {
+			opts = append(opts, decorators.WithMaxDelay(time.Duration(r.MaxDelay)))
+		}
+		switch r.Jitter {
+		case "", "none":
+		case "full":
+			opts = append(opts, decorators.WithJitter(decorators.JitterFull))
+		case "decorrelated":
+			opts = append(opts, decorators.WithJitter(decorators.JitterDecorrelated))
+		default:
+			return nil, fmt.Errorf("unknown jitter %q", r.Jitter)
+		}
+		b.Retry(r.MaxRetries, time.Duration(r.BaseDelay), opts...)
+	}
+	if cb := m.CircuitBreaker; cb != nil {
+		var opts []decorators.CircuitBreakerOption
+		if cb.WindowSize > 0 || cb.Window > 0 {
+			opts = append(opts, decorators.WithSlidingWindow(cb.WindowSize, time.Duration(cb.Window)))
+		}
+		if cb.MinCalls > 0 {
+			opts = append(opts, decorators.WithMinimumCalls(cb.MinCalls))
+		}
+		if cb.FailureRate > 0 {
+			opts = append(opts, decorators.WithFailureRateThreshold(cb.FailureRate))
+		}
+		if cb.HalfOpenProbes > 0 {
+			opts = append(opts, decorators.WithHalfOpenProbes(cb.HalfOpenProbes))
+		}
+		if cb.PerSymbol {
+			opts = append(opts, decorators.WithPerSymbol())
+		}
+		b.CircuitBreaker(cb.FailureThreshold, time.Duration(cb.ResetTimeout), opts...)
+	}
+	if bh := m.Bulkhead; bh != nil {
+		var opts []decorators.BulkheadOption
+		if bh.Queue > 0 {
+			opts = append(opts, decorators.WithQueue(bh.Queue, time.Duration(bh.QueueTimeout)))
+		}
+		b.Bulkhead(bh.MaxConcurrent, opts...)
+	}
+	if rl := m.RateLimit; rl != nil && !rl.Disabled {
+		if rl.Adaptive && rl.Shared == "" {
+			b.AdaptiveRateLimit(rate(*rl), decorators.WithAdaptiveLimiter(pc.limiter))
+		} else {
+			b.RateLimit(pc.limiter)
+		}
+	}
+	if m.Timeout > 0 {
+		b.Timeout(time.Duration(m.Timeout))
+	}
+	return b.Middleware(), nil
+}
+
+func rate(rl RateLimitConfig) float64 {
+	per := time.Duration(rl.Per)
+	if per <= 0 {
+		per = time.Second
+	}
+	return rl.Requests / per.Seconds()
+}
+
+// defaultRateLimit returns the limit applied when a provider configures none.
+// CoinGecko enforces a per-plan allowance, so its plan's limit is used.
+func defaultRateLimit(pc ProviderConfig) *RateLimitConfig {
+	if pc.Type != "coingecko" {
+		return nil
+	}
+	plan := coingecko.PlanPublic
+	if pc.APIKey != "" {
+		// Invalid plans are rejected by the factory
+		plan, _ = coingecko.ParsePlan(pc.Plan)
+	}
+	requests, per := plan.RateLimit()
+	return &RateLimitConfig{Requests: float64(requests), Per: Duration(per), Burst: 1}
+}
+
+// providerLimiter resolves the limiter of the provider's rate limit stage, created
+// before the provider so its factory can share it
+func providerLimiter(pc ProviderConfig, limiters map[string]*decorators.Limiter) (*decorators.Limiter, error) {
+	rl := pc.Middleware.RateLimit
+	if rl == nil {
+		rl = defaultRateLimit(pc)
+	}
+	switch {
+	case rl == nil || rl.Disabled:
+		return nil, nil
+	case rl.Shared != "":
+		l, ok := limiters[rl.Shared]
+		if !ok {
+			return nil, fmt.Errorf("unknown shared rate limiter %q", rl.Shared)
+		}
+		return l, nil
+	case rl.Adaptive:
+		r := rate(*rl)
+		return decorators.NewLimiter(r, int(math.Max(1, r))), nil
+	default:
+		return newLimiter(*rl), nil
+	}
+}
+
+func newLimiter(rl RateLimitConfig) *decorators.Limiter {
+	burst := rl.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return decorators.NewLimiter(rate(rl), burst)
+}
+
+// expandEnv resolves ${NAME} references, failing on unset variables so a missing
+// secret is not silently replaced by an empty string
+func expandEnv(pc ProviderConfig) (ProviderConfig, error) {
+	var missing []string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return v
+		})
+	}
+
+	pc.BaseURL = expand(pc.BaseURL)
+	pc.APIKey = expand(pc.APIKey)
+	pc.UserAgent = expand(pc.UserAgent)
+	if pc.Symbols != nil {
+		sc := *pc.Symbols
+		sc.CacheFile = expand(sc.CacheFile)
+		pc.Symbols = &sc
+	}
+	if len(missing) > 0 {
+		return pc, fmt.Errorf("unset environment variable(s): %s", strings.Join(missing, ", "))
+	}
+	return pc, nil
+}
@@ -0,0 +1,235 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/config"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+type staticProvider struct {
+	key   string
+	calls int
+}
+
+func (s *staticProvider) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	s.calls++
+	if s.calls == 1 {
+		return nil, errors.New("flaky")
+	}
+	return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(42), Source: s.key}, nil
+}
+
+const sample = `{
+	"batch_concurrency": 4,
+	"rate_limiters": {
+		"upstream": {"requests": 30, "per": "1m", "burst": 5}
+	},
+	"providers": {
+		"static": {
+			"type": "static",
+			"api_key": "${MARKETS_TEST_KEY}",
+			"preset": "test",
+			"middleware": {
+				"retry": {"max_retries": 2, "base_delay": "1ms", "jitter": "full"},
+				"circuit_breaker": {"failure_threshold": 5, "reset_timeout": "30s"},
+				"rate_limit": {"shared": "upstream"},
+				"cache": {"size": 10, "ttl": "1m"}
+			}
+		}
+	}
+}`
+
+func init() {
+	config.RegisterProviderType("static", func(cfg config.ProviderConfig) (ports.Provider, error) {
+		return &staticProvider{key: cfg.APIKey}, nil
+	})
+}
+
+func TestBuild(t *testing.T) {
+	t.Setenv("MARKETS_TEST_KEY", "secret")
+
+	cfg, err := config.Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, err := config.Build(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The retry stage recovers from the first failure
+	q, err := client.GetQuote(context.Background(), "static", "ABC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.NewDecimalFromInt(42)) || q.Source != "secret" {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	// 1. Missing environment variable
+	cfg, err := config.Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := config.Build(cfg); err == nil || !strings.Contains(err.Error(), "MARKETS_TEST_KEY") {
+		t.Errorf("expected unset variable error, got %v", err)
+	}
+
+	// 2. Unknown fields are rejected
+	if _, err := config.Parse(strings.NewReader(`{"providers": {"x": {"type": "yahoo", "retries": 3}}}`)); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	// 3. Unknown provider type
+	cfg, _ = config.Parse(strings.NewReader(`{"providers": {"x": {"type": "bloomberg"}}}`))
+	if _, err := config.Build(cfg); err == nil {
+		t.Error("expected error for unknown provider type")
+	}
+
+	// 4. CoinGecko keys need a plan, and only CoinGecko has plans and symbol resolution
+	for _, doc := range []string{
+		`{"providers": {"x": {"type": "coingecko", "api_key": "k"}}}`,
+		`{"providers": {"x": {"type": "coingecko", "api_key": "k", "plan": "enterprise"}}}`,
+		`{"providers": {"x": {"type": "yahoo", "plan": "pro"}}}`,
+		`{"providers": {"x": {"type": "yahoo", "symbols": {}}}}`,
+		`{"providers": {"x": {"type": "coingecko", "symbols": {"disambiguation": "first"}}}}`,
+	} {
+		cfg, _ = config.Parse(strings.NewReader(doc))
+		if _, err := config.Build(cfg); err == nil {
+			t.Errorf("expected error for %s", doc)
+		}
+	}
+
+	// 5. Routes must name a configured provider and a known asset type
+	for _, doc := range []string{
+		`{"providers": {"x": {"type": "yahoo"}}, "routes": [{"provider": "y"}]}`,
+		`{"providers": {"x": {"type": "yahoo"}}, "routes": [{"asset_type": "bond", "provider": "x"}]}`,
+	} {
+		cfg, _ = config.Parse(strings.NewReader(doc))
+		if _, err := config.Build(cfg); err == nil {
+			t.Errorf("expected error for %s", doc)
+		}
+	}
+
+	// 6. Rate limits must allow some requests, unless they refer to a shared limiter
+	for doc, path := range map[string]string{
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"rate_limit": {"burst": 5}}}}}`:                      "providers.x.middleware.rate_limit.requests",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"rate_limit": {"requests": 0, "adaptive": true}}}}}`: "providers.x.middleware.rate_limit.requests",
+		`{"rate_limiters": {"cg": {"requests": -1}}, "providers": {"x": {"type": "yahoo"}}}`:                       "rate_limiters.cg.requests",
+	} {
+		if _, err := config.Parse(strings.NewReader(doc)); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("expected error naming %s for %s, got %v", path, doc, err)
+		}
+	}
+	if _, err := config.Build(&config.Config{Providers: map[string]config.ProviderConfig{
+		"x": {Type: "yahoo", Middleware: config.MiddlewareConfig{RateLimit: &config.RateLimitConfig{}}},
+	}}); err == nil {
+		t.Error("expected Build to reject a zero rate limit")
+	}
+	cfg, err = config.Parse(strings.NewReader(`{"rate_limiters": {"cg": {"requests": 10}}, "providers": {"x": {"type": "yahoo", "middleware": {"rate_limit": {"shared": "cg"}}}}}`))
+	if err != nil {
+		t.Fatalf("a shared limiter needs no requests of its own: %v", err)
+	}
+	if _, err := config.Build(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// 7. Middleware settings that would be silently clamped or do nothing
+	for doc, path := range map[string]string{
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"cache": {"size": 0, "ttl": "1m"}}}}}`:                              "providers.x.middleware.cache.size",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"cache": {"size": 10, "ttl": "0s"}}}}}`:                             "providers.x.middleware.cache.ttl",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"retry": {"max_retries": -1, "base_delay": "1s"}}}}}`:               "providers.x.middleware.retry.max_retries",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"retry": {"max_retries": 3}}}}}`:                                    "providers.x.middleware.retry.base_delay",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"circuit_breaker": {"reset_timeout": "30s"}}}}}`:                    "providers.x.middleware.circuit_breaker.failure_threshold",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"circuit_breaker": {"failure_threshold": 5}}}}}`:                    "providers.x.middleware.circuit_breaker.reset_timeout",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"circuit_breaker": {"failure_rate": 50, "reset_timeout": "1s"}}}}}`: "providers.x.middleware.circuit_breaker.failure_rate",
+		`{"providers": {"x": {"type": "yahoo", "middleware": {"bulkhead": {"max_concurrent": 0}}}}}`:                              "providers.x.middleware.bulkhead.max_concurrent",
+		`{"rate_limiters": {"cg": {"disabled": true}}, "providers": {"x": {"type": "yahoo"}}}`:                                    "rate_limiters.cg.disabled",
+	} {
+		if _, err := config.Parse(strings.NewReader(doc)); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("expected error naming %s for %s, got %v", path, doc, err)
+		}
+	}
+
+	// 8. Invalid duration
+	if _, err := config.Parse(strings.NewReader(`{"providers": {"x": {"type": "yahoo", "middleware": {"timeout": 5}}}}`)); err == nil {
+		t.Error("expected error for numeric duration")
+	}
+}
+
+func TestRegistrySharesLimiter(t *testing.T) {
+	var catalog atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/coins/list" {
+			catalog.Add(1)
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	// The single token is taken by the rate limit stage, so the catalog download made
+	// from inside the provider has to wait for the next one
+	doc := `{"providers": {"cg": {"type": "coingecko", "base_url": "` + srv.URL + `", "symbols": {},
+		"middleware": {"rate_limit": {"requests": 1, "per": "1h"}}}}}`
+	cfg, err := config.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, err := config.Build(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetQuote(ctx, "cg", "BTC"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the catalog download to wait for the limiter, got %v", err)
+	}
+	if n := catalog.Load(); n != 0 {
+		t.Errorf("expected no catalog request, got %d", n)
+	}
+}
+
+func TestDisableDefaultRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bitcoin": {"usd": 1}}`))
+	}))
+	defer srv.Close()
+
+	// Without "disabled" the public plan limit would hold the second call for seconds
+	doc := `{"providers": {"cg": {"type": "coingecko", "base_url": "` + srv.URL + `",
+		"middleware": {"rate_limit": {"disabled": true}}}}}`
+	cfg, err := config.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, err := config.Build(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetQuote(ctx, "cg", "bitcoin"); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	if _, err := config.LoadClient("../../examples/config.json"); err != nil {
+		t.Fatalf("example config should build: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// AdaptiveRateLimit is a rate limiting decorator that learns the upstream limit. It
+// halves its rate (multiplicative decrease) on 429 responses, follows the X-RateLimit-*
+// headers reported by the provider, and slowly raises the rate again (additive increase)
+// while calls succeed.
+type AdaptiveRateLimit struct {
+	provider ports.Provider
+	limiter  *Limiter
+	minRate  float64
+	maxRate  float64
+	step     float64
+	interval time.Duration
+	factor   float64
+
+	collector MetricsCollector
+	name      string
+
+	mu           sync.Mutex
+	lastChange   time.Time
+	lastDecrease time.Time
+	pausedUntil  time.Time
+}
+
+// decreaseCooldown is the minimum time between two multiplicative decreases
+const decreaseCooldown = time.Second
+
+// AdaptiveOption configures an AdaptiveRateLimit
+type AdaptiveOption func(*AdaptiveRateLimit)
+
+// WithRateBounds keeps the adapted rate within [min, max] requests per second
+func WithRateBounds(min, max float64) AdaptiveOption {
+	return func(a *AdaptiveRateLimit) {
+		a.minRate = min
+		a.maxRate = max
+	}
+}
+
+// WithAdditiveIncrease raises the rate by step after every interval without rate limiting
+func WithAdditiveIncrease(step float64, interval time.Duration) AdaptiveOption {
+	return func(a *AdaptiveRateLimit) {
+		a.step = step
+		a.interval = interval
+	}
+}
+
+// WithMultiplicativeDecrease multiplies the rate by factor (0-1) when rate limited
+func WithMultiplicativeDecrease(factor float64) AdaptiveOption {
+	return func(a *AdaptiveRateLimit) {
+		a.factor = factor
+	}
+}
+
+// WithRateMetrics reports the effective rate as the "rate_limit" gauge when collector
+// implements GaugeCollector
+func WithRateMetrics(collector MetricsCollector, name string) AdaptiveOption {
+	return func(a *AdaptiveRateLimit) {
+		a.collector = collector
+		a.name = name
+	}
+}
+
+// WithAdaptiveLimiter adapts the rate of limiter instead of a private one, so other
+// callers waiting on limiter, such as a provider's background requests, follow the
+// learned rate too
+func WithAdaptiveLimiter(limiter *Limiter) AdaptiveOption {
+	return func(a *AdaptiveRateLimit) {
+		if limiter != nil {
+			a.limiter = limiter
+		}
+	}
+}
+
+// NewAdaptiveRateLimit starts at rate requests per second. By default the rate stays
+// between rate/100 and rate*2, recovers by 10% of rate every 10 seconds and halves on 429.
+func NewAdaptiveRateLimit(provider ports.Provider, rate float64, opts ...AdaptiveOption) *AdaptiveRateLimit {
+	a := &AdaptiveRateLimit{
+		provider: provider,
+		limiter:  NewLimiter(rate, int(math.Max(1, rate))),
+		minRate:  rate / 100,
+		maxRate:  rate * 2,
+		step:     rate / 10,
+		interval: 10 * time.Second,
+		factor:   0.5,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.limiter.SetRate(rate)
+	a.lastChange = time.Now()
+	a.report(rate)
+	return a
+}
+
+func (a *AdaptiveRateLimit) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return throttled(ctx, a, func(ctx context.Context) (*domain.Quote, error) {
+		return a.provider.GetQuote(ctx, symbol)
+	})
+}
+
+// GetQuotes counts a native batch as one request
+func (a *AdaptiveRateLimit) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(a.provider)
+	if !ok {
+		return fanOut(ctx, a.GetQuote, symbols)
+	}
+	return throttled(ctx, a, func(ctx context.Context) (map[string]*domain.Quote, error) {
+		return bp.GetQuotes(ctx, symbols)
+	})
+}
+
+func (a *AdaptiveRateLimit) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(a.provider)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	return throttled(ctx, a, func(ctx context.Context) ([]domain.Candle, error) {
+		return hp.GetHistory(ctx, symbol, interval, from, to)
+	})
+}
+
+func (a *AdaptiveRateLimit) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, a.provider, symbols)
+}
+
+func (a *AdaptiveRateLimit) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, a.provider, symbols)
+}
+
+// throttled runs fn as one request against the adapted rate and learns from its outcome
+func throttled[T any](ctx context.Context, a *AdaptiveRateLimit, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := a.waitPause(ctx); err != nil {
+		return zero, err
+	}
+	if err := a.limiter.Wait(ctx, 1); err != nil {
+		return zero, err
+	}
+
+	ctx = ports.WithRateLimitObserver(ctx, a.observe)
+	v, err := fn(ctx)
+
+	var rlErr *domain.RateLimitError
+	switch {
+	case errors.As(err, &rlErr):
+		a.observe(domain.RateLimitInfo{StatusCode: http.StatusTooManyRequests, Limit: -1, Remaining: -1, RetryAfter: rlErr.RetryAfter})
+	case err == nil:
+		a.recover()
+	}
+	return v, err
+}
+
+// Rate returns the current effective rate in requests per second
+func (a *AdaptiveRateLimit) Rate() float64 {
+	return a.limiter.Rate()
+}
+
+// Close releases the underlying limiter
+func (a *AdaptiveRateLimit) Close() error {
+	return a.limiter.Close()
+}
+
+func (a *AdaptiveRateLimit) observe(info domain.RateLimitInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	rate := a.limiter.Rate()
+	next := rate
+
+	if info.StatusCode == http.StatusTooManyRequests || info.RetryAfter > 0 {
+		// Concurrent calls often hit the same 429, and each is reported both by the
+		// provider and through its error; only back off once per cooldown
+		if now.Sub(a.lastDecrease) >= decreaseCooldown {
+			next = rate * a.factor
+			a.lastDecrease = now
+		}
+		if info.RetryAfter > 0 {
+			if until := now.Add(info.RetryAfter); until.After(a.pausedUntil) {
+				a.pausedUntil = until
+			}
+		}
+	}
+
+	// Never plan more than the upstream says is left until its window resets
+	if info.Remaining >= 0 && info.Reset > 0 {
+		if allowed := float64(info.Remaining) / info.Reset.Seconds(); allowed < next {
+			next = allowed
+		}
+	}
+
+	if next != rate {
+		a.setRate(next, now)
+	}
+}
+
+func (a *AdaptiveRateLimit) recover() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(a.lastChange) < a.interval {
+		return
+	}
+	rate := a.limiter.Rate()
+	if rate < a.maxRate {
+		a.setRate(rate+a.step, now)
+	}
+}
+
+// setRate applies the bounds and reports the new rate. Must hold a.mu.
+func (a *AdaptiveRateLimit) setRate(rate float64, now time.Time) {
+	rate = math.Max(a.minRate, math.Min(a.maxRate, rate))
+	a.limiter.SetRate(rate)
+	a.lastChange = now
+	a.report(rate)
+}
+
+func (a *AdaptiveRateLimit) report(rate float64) {
+	if a.collector != nil {
+		setGauge(a.collector, a.name, "rate_limit", rate)
+	}
+}
+
+// waitPause blocks while the upstream asked us to back off through Retry-After
+func (a *AdaptiveRateLimit) waitPause(ctx context.Context) error {
+	a.mu.Lock()
+	wait := time.Until(a.pausedUntil)
+	a.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
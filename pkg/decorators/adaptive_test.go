@@ -0,0 +1,106 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+func TestAdaptiveRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	limited := true
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if limited {
+				return nil, &domain.RateLimitError{}
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	metrics := &MockCollector{}
+	a := decorators.NewAdaptiveRateLimit(mock, 100,
+		decorators.WithRateBounds(1, 200),
+		decorators.WithAdditiveIncrease(10, time.Millisecond),
+		decorators.WithRateMetrics(metrics, "test"),
+	)
+	defer a.Close()
+
+	if metrics.Gauge("rate_limit") != 100 {
+		t.Errorf("expected initial gauge 100, got %v", metrics.Gauge("rate_limit"))
+	}
+
+	// 1. 429 halves the rate, repeated 429s within the cooldown do not
+	_, err := a.GetQuote(ctx, "BTC")
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	_, _ = a.GetQuote(ctx, "BTC")
+	if a.Rate() != 50 {
+		t.Errorf("expected rate 50 after 429, got %v", a.Rate())
+	}
+	if metrics.Gauge("rate_limit") != 50 {
+		t.Errorf("expected gauge 50, got %v", metrics.Gauge("rate_limit"))
+	}
+
+	// 2. Successful calls recover additively
+	limited = false
+	time.Sleep(2 * time.Millisecond)
+	_, _ = a.GetQuote(ctx, "BTC")
+	if a.Rate() != 60 {
+		t.Errorf("expected rate 60 after recovery, got %v", a.Rate())
+	}
+}
+
+func TestAdaptiveRateLimitHeaders(t *testing.T) {
+	ctx := context.Background()
+
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			// 5 requests left for the next 10 seconds
+			ports.ReportRateLimit(ctx, domain.RateLimitInfo{Limit: 30, Remaining: 5, Reset: 10 * time.Second})
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	a := decorators.NewAdaptiveRateLimit(mock, 10, decorators.WithRateBounds(0.1, 10))
+	defer a.Close()
+
+	if _, err := a.GetQuote(ctx, "BTC"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(a.Rate()-0.5) > 1e-9 {
+		t.Errorf("expected rate 0.5 from headers, got %v", a.Rate())
+	}
+}
+
+func TestAdaptiveRateLimitRetryAfter(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			if calls == 1 {
+				return nil, &domain.RateLimitError{RetryAfter: 30 * time.Millisecond}
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	a := decorators.NewAdaptiveRateLimit(mock, 1000)
+	defer a.Close()
+
+	_, _ = a.GetQuote(ctx, "BTC")
+	start := time.Now()
+	if _, err := a.GetQuote(ctx, "BTC"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("expected to pause for Retry-After, waited %v", elapsed)
+	}
+}
@@ -0,0 +1,181 @@
+package decorators
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Bulkhead is a decorator that caps the number of concurrent in-flight calls,
+// optionally queueing a bounded number of callers, and rejects the rest with
+// domain.ErrBulkheadFull
+type Bulkhead struct {
+	provider     ports.Provider
+	slots        chan struct{}
+	maxQueue     int
+	queueTimeout time.Duration
+	collector    MetricsCollector
+	name         string
+
+	inFlight atomic.Int64
+	mu       sync.Mutex
+	queued   int
+}
+
+// BulkheadOption configures a Bulkhead
+type BulkheadOption func(*Bulkhead)
+
+// WithQueue lets up to size callers wait for a free slot, each for at most timeout
+// (0 waits until the caller's context ends)
+func WithQueue(size int, timeout time.Duration) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.maxQueue = size
+		b.queueTimeout = timeout
+	}
+}
+
+// WithBulkheadMetrics reports the "in_flight" and "queue_depth" gauges when collector
+// implements GaugeCollector, and rejected calls as requests with status "rejected"
+func WithBulkheadMetrics(collector MetricsCollector, name string) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.collector = collector
+		b.name = name
+	}
+}
+
+func NewBulkhead(provider ports.Provider, maxConcurrent int, opts ...BulkheadOption) *Bulkhead {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	b := &Bulkhead{
+		provider: provider,
+		slots:    make(chan struct{}, maxConcurrent),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *Bulkhead) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	if err := b.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer b.release()
+
+	return b.provider.GetQuote(ctx, symbol)
+}
+
+// GetQuotes takes a single slot for a native batch
+func (b *Bulkhead) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(b.provider)
+	if !ok {
+		return fanOut(ctx, b.GetQuote, symbols)
+	}
+	if err := b.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer b.release()
+
+	return bp.GetQuotes(ctx, symbols)
+}
+
+func (b *Bulkhead) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(b.provider)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	if err := b.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer b.release()
+
+	return hp.GetHistory(ctx, symbol, interval, from, to)
+}
+
+// Subscribe does not take a slot: a subscription would hold it for its lifetime
+func (b *Bulkhead) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, b.provider, symbols)
+}
+
+func (b *Bulkhead) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, b.provider, symbols)
+}
+
+// InFlight returns the number of calls currently running
+func (b *Bulkhead) InFlight() int {
+	return int(b.inFlight.Load())
+}
+
+// Queued returns the number of callers waiting for a slot
+func (b *Bulkhead) Queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queued
+}
+
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		b.report("in_flight", float64(b.inFlight.Add(1)))
+		return nil
+	default:
+	}
+
+	b.mu.Lock()
+	if b.queued >= b.maxQueue {
+		b.mu.Unlock()
+		b.reject()
+		return domain.ErrBulkheadFull
+	}
+	b.queued++
+	b.report("queue_depth", float64(b.queued))
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.queued--
+		b.report("queue_depth", float64(b.queued))
+		b.mu.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := time.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		b.report("in_flight", float64(b.inFlight.Add(1)))
+		return nil
+	case <-timeout:
+		b.reject()
+		return fmt.Errorf("%w: queued for %s", domain.ErrBulkheadFull, b.queueTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) release() {
+	b.report("in_flight", float64(b.inFlight.Add(-1)))
+	<-b.slots
+}
+
+func (b *Bulkhead) reject() {
+	if b.collector != nil {
+		b.collector.IncRequest(b.name, "rejected")
+	}
+}
+
+func (b *Bulkhead) report(gauge string, value float64) {
+	if b.collector != nil {
+		setGauge(b.collector, b.name, gauge, value)
+	}
+}
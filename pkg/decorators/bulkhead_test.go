@@ -0,0 +1,81 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestBulkhead(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			<-release
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	metrics := &MockCollector{}
+	b := decorators.NewBulkhead(mock, 2,
+		decorators.WithQueue(1, 20*time.Millisecond),
+		decorators.WithBulkheadMetrics(metrics, "test"),
+	)
+
+	// 1. Fill both slots
+	done := make(chan error, 3)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := b.GetQuote(ctx, "AAPL")
+			done <- err
+		}()
+	}
+	waitUntil(t, func() bool { return b.InFlight() == 2 })
+	if metrics.Gauge("in_flight") != 2 {
+		t.Errorf("expected in_flight gauge 2, got %v", metrics.Gauge("in_flight"))
+	}
+
+	// 2. One caller may queue; it times out since the slots stay busy
+	go func() {
+		_, err := b.GetQuote(ctx, "AAPL")
+		done <- err
+	}()
+	waitUntil(t, func() bool { return b.Queued() == 1 })
+
+	// 3. The queue is full, so the next caller fails fast
+	if _, err := b.GetQuote(ctx, "AAPL"); !errors.Is(err, domain.ErrBulkheadFull) {
+		t.Errorf("expected ErrBulkheadFull, got %v", err)
+	}
+
+	if err := <-done; !errors.Is(err, domain.ErrBulkheadFull) {
+		t.Errorf("expected queued caller to time out, got %v", err)
+	}
+	if metrics.Count("rejected") != 2 {
+		t.Errorf("expected 2 rejections, got %d", metrics.Count("rejected"))
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if b.InFlight() != 0 || b.Queued() != 0 {
+		t.Errorf("expected empty bulkhead, got %d in flight, %d queued", b.InFlight(), b.Queued())
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,287 @@
+package decorators
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Cache is a decorator that keeps recent quotes in a bounded in-memory LRU
+type Cache struct {
+	provider       ports.Provider
+	size           int
+	ttl            time.Duration
+	staleTTL       time.Duration
+	refreshTimeout time.Duration
+	negativeTTL    time.Duration
+	collector      MetricsCollector
+	name           string
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // front is most recently used
+}
+
+type cacheEntry struct {
+	symbol     string
+	quote      *domain.Quote
+	err        error // set for negatively cached lookups
+	expires    time.Time
+	refreshing bool
+}
+
+// CacheOption configures a Cache
+type CacheOption func(*Cache)
+
+// WithStaleWhileRevalidate keeps serving an expired quote for up to window after
+// its TTL while a single background call refreshes it
+func WithStaleWhileRevalidate(window time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.staleTTL = window
+	}
+}
+
+// WithRefreshTimeout bounds the background call refreshing a stale quote. Defaults to
+// the stale window, after which the refreshed quote would no longer be served in its place.
+func WithRefreshTimeout(d time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.refreshTimeout = d
+	}
+}
+
+// WithNegativeTTL caches domain.ErrSymbolNotFound errors for ttl so unknown symbols
+// do not hit the upstream on every call
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithCacheMetrics reports cache hits, misses and stale hits through collector
+// as requests with the status "cache_hit", "cache_miss" and "cache_stale"
+func WithCacheMetrics(collector MetricsCollector, name string) CacheOption {
+	return func(c *Cache) {
+		c.collector = collector
+		c.name = name
+	}
+}
+
+// NewCache creates a cache holding at most size symbols, each fresh for ttl
+func NewCache(provider ports.Provider, size int, ttl time.Duration, opts ...CacheOption) *Cache {
+	if size <= 0 {
+		size = 1
+	}
+	c := &Cache{
+		provider: provider,
+		size:     size,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Cache) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if el, ok := c.items[symbol]; ok {
+		e := el.Value.(*cacheEntry)
+		switch {
+		case now.Before(e.expires):
+			c.order.MoveToFront(el)
+			quote, err := e.quote.Clone(), e.err
+			c.mu.Unlock()
+			c.report("cache_hit")
+			return quote, err
+		case e.err == nil && now.Before(e.expires.Add(c.staleTTL)):
+			c.order.MoveToFront(el)
+			quote := e.quote.Clone()
+			if !e.refreshing {
+				e.refreshing = true
+				go c.refresh(ctx, symbol)
+			}
+			c.mu.Unlock()
+			c.report("cache_stale")
+			return quote, nil
+		}
+	}
+	c.mu.Unlock()
+
+	c.report("cache_miss")
+	quote, err := c.provider.GetQuote(ctx, symbol)
+	c.store(symbol, quote, err)
+	if err != nil {
+		return nil, err
+	}
+	return quote.Clone(), nil
+}
+
+// GetQuotes serves fresh entries from the cache and fetches the others in one native
+// batch; stale entries are refetched rather than revalidated in the background.
+// Symbols the batch returns neither a quote nor an error for are reported as
+// domain.ErrSymbolNotFound. Without native batching every symbol goes through GetQuote.
+func (c *Cache) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(c.provider)
+	if !ok {
+		return fanOut(ctx, c.GetQuote, symbols)
+	}
+
+	now := time.Now()
+	quotes := make(map[string]*domain.Quote, len(symbols))
+	failed := make(map[string]error)
+	var misses []string
+
+	c.mu.Lock()
+	for _, s := range symbols {
+		if el, ok := c.items[s]; ok {
+			if e := el.Value.(*cacheEntry); now.Before(e.expires) {
+				c.order.MoveToFront(el)
+				if e.err != nil {
+					failed[s] = e.err
+				} else {
+					quotes[s] = e.quote.Clone()
+				}
+				continue
+			}
+		}
+		misses = append(misses, s)
+	}
+	c.mu.Unlock()
+
+	for range len(symbols) - len(misses) {
+		c.report("cache_hit")
+	}
+	if len(misses) == 0 {
+		return batchResult(quotes, failed)
+	}
+	for range misses {
+		c.report("cache_miss")
+	}
+
+	got, err := bp.GetQuotes(ctx, misses)
+	var batchErr *domain.BatchError
+	if err != nil && !errors.As(err, &batchErr) && len(misses) == len(symbols) {
+		return nil, err
+	}
+	for _, s := range misses {
+		if q := got[s]; q != nil {
+			c.store(s, q, nil)
+			quotes[s] = q.Clone()
+			continue
+		}
+		symbolErr := err
+		if batchErr != nil {
+			symbolErr = batchErr.Errors[s]
+		}
+		if symbolErr == nil {
+			// The provider returned neither a quote nor an error for s
+			symbolErr = fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, s)
+		}
+		c.store(s, nil, symbolErr)
+		failed[s] = symbolErr
+	}
+	return batchResult(quotes, failed)
+}
+
+// GetHistory is not cached
+func (c *Cache) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return getHistory(ctx, c.provider, symbol, interval, from, to)
+}
+
+func (c *Cache) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, c.provider, symbols)
+}
+
+func (c *Cache) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, c.provider, symbols)
+}
+
+// Invalidate drops any cached entry for symbol
+func (c *Cache) Invalidate(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[symbol]; ok {
+		c.order.Remove(el)
+		delete(c.items, symbol)
+	}
+}
+
+// Len returns the number of cached symbols
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) refresh(ctx context.Context, symbol string) {
+	// The caller has its stale quote and may be gone, but a hung upstream must not
+	// keep the entry refreshing forever
+	timeout := c.refreshTimeout
+	if timeout <= 0 {
+		timeout = c.staleTTL
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	quote, err := c.provider.GetQuote(ctx, symbol)
+
+	c.mu.Lock()
+	if el, ok := c.items[symbol]; ok {
+		el.Value.(*cacheEntry).refreshing = false
+	}
+	c.mu.Unlock()
+
+	c.store(symbol, quote, err)
+}
+
+// store records the outcome of an upstream call. Transient errors are not cached,
+// so an existing stale entry stays in place until it ages out.
+func (c *Cache) store(symbol string, quote *domain.Quote, err error) {
+	ttl := c.ttl
+	if err != nil {
+		if c.negativeTTL <= 0 || !errors.Is(err, domain.ErrSymbolNotFound) {
+			return
+		}
+		ttl = c.negativeTTL
+		quote = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &cacheEntry{
+		symbol:  symbol,
+		quote:   quote.Clone(),
+		err:     err,
+		expires: time.Now().Add(ttl),
+	}
+	if el, ok := c.items[symbol]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[symbol] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).symbol)
+	}
+}
+
+func (c *Cache) report(status string) {
+	if c.collector != nil {
+		c.collector.IncRequest(c.name, status)
+	}
+}
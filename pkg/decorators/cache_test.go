@@ -0,0 +1,197 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			atomic.AddInt32(&calls, 1)
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	metrics := &MockCollector{}
+	c := decorators.NewCache(mock, 2, 50*time.Millisecond, decorators.WithCacheMetrics(metrics, "test"))
+
+	// 1. Miss then hit
+	q, err := c.GetQuote(ctx, "BTC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q.Price = domain.NewDecimalFromInt(1) // mutating the returned quote must not affect the cache
+
+	q, _ = c.GetQuote(ctx, "BTC")
+	if !q.Price.Equal(domain.NewDecimalFromInt(100)) {
+		t.Errorf("expected cached price 100, got %v", q.Price)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls)
+	}
+	if metrics.Count("cache_hit") != 1 || metrics.Count("cache_miss") != 1 {
+		t.Errorf("unexpected metrics: %v", metrics.Requests)
+	}
+
+	// 2. LRU eviction: BTC was used most recently, so ETH is evicted by SOL
+	_, _ = c.GetQuote(ctx, "ETH")
+	_, _ = c.GetQuote(ctx, "BTC")
+	_, _ = c.GetQuote(ctx, "SOL")
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+	calls = 0
+	_, _ = c.GetQuote(ctx, "BTC")
+	_, _ = c.GetQuote(ctx, "ETH")
+	if calls != 1 {
+		t.Errorf("expected only ETH to be refetched, got %d calls", calls)
+	}
+
+	// 3. Expiry
+	time.Sleep(60 * time.Millisecond)
+	calls = 0
+	_, _ = c.GetQuote(ctx, "BTC")
+	if calls != 1 {
+		t.Errorf("expected expired entry to be refetched, got %d calls", calls)
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			atomic.AddInt32(&calls, 1)
+			if symbol == "NOPE" {
+				return nil, fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, symbol)
+			}
+			return nil, errors.New("connection reset")
+		},
+	}
+	c := decorators.NewCache(mock, 10, time.Minute, decorators.WithNegativeTTL(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetQuote(ctx, "NOPE"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected not-found to be cached, got %d calls", calls)
+	}
+
+	// Transient errors are never cached
+	calls = 0
+	_, _ = c.GetQuote(ctx, "BTC")
+	_, _ = c.GetQuote(ctx, "BTC")
+	if calls != 2 {
+		t.Errorf("expected transient errors to bypass the cache, got %d calls", calls)
+	}
+}
+
+func TestCacheBatchOmittedSymbol(t *testing.T) {
+	// The provider drops GONE without reporting an error for it
+	mock := &MockFullProvider{
+		QuotesFn: func(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+			return map[string]*domain.Quote{"BTC": {Symbol: "BTC", Price: domain.NewDecimalFromInt(1)}}, nil
+		},
+	}
+	c := decorators.NewCache(mock, 10, time.Minute)
+
+	quotes, err := c.GetQuotes(context.Background(), []string{"BTC", "GONE"})
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if !errors.Is(batchErr.Errors["GONE"], domain.ErrSymbolNotFound) {
+		t.Errorf("expected GONE to be reported as not found, got %v", batchErr.Errors)
+	}
+	if len(quotes) != 1 || quotes["BTC"] == nil {
+		t.Errorf("expected the BTC quote only, got %v", quotes)
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	var price int64 = 100
+	refreshed := make(chan struct{}, 1)
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			p := atomic.LoadInt64(&price)
+			if p != 100 {
+				defer func() { refreshed <- struct{}{} }()
+			}
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(p)}, nil
+		},
+	}
+	c := decorators.NewCache(mock, 10, 20*time.Millisecond, decorators.WithStaleWhileRevalidate(time.Second))
+
+	_, _ = c.GetQuote(ctx, "BTC")
+	time.Sleep(30 * time.Millisecond)
+	atomic.StoreInt64(&price, 200)
+
+	// Stale value is served immediately while the refresh runs in the background
+	q, err := c.GetQuote(ctx, "BTC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.NewDecimalFromInt(100)) {
+		t.Errorf("expected stale price 100, got %v", q.Price)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not run")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	q, _ = c.GetQuote(ctx, "BTC")
+	if !q.Price.Equal(domain.NewDecimalFromInt(200)) {
+		t.Errorf("expected refreshed price 200, got %v", q.Price)
+	}
+}
+
+func TestCacheRefreshTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if calls.Add(1) == 2 {
+				<-ctx.Done() // the first refresh hangs
+				return nil, ctx.Err()
+			}
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	c := decorators.NewCache(mock, 10, 10*time.Millisecond,
+		decorators.WithStaleWhileRevalidate(time.Second),
+		decorators.WithRefreshTimeout(20*time.Millisecond))
+
+	_, _ = c.GetQuote(ctx, "BTC")
+	time.Sleep(20 * time.Millisecond)
+	_, _ = c.GetQuote(ctx, "BTC") // starts the hung refresh
+
+	// Once it times out, a later stale hit refreshes again
+	time.Sleep(40 * time.Millisecond)
+	_, _ = c.GetQuote(ctx, "BTC")
+	waitUntil := time.Now().Add(time.Second)
+	for calls.Load() < 3 {
+		if time.Now().After(waitUntil) {
+			t.Fatal("hung refresh was never abandoned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,81 @@
+package decorators
+
+import (
+	"slices"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// forward returns the capabilities of the wrapped provider. Decorators implement every
+// optional interface and pass it through, so nothing is lost on the way.
+func forward(inner ports.Provider) ports.Capabilities {
+	return ports.CapabilitiesOf(inner)
+}
+
+// common returns what every source supports. Multi-source decorators send the same
+// symbol to each source, so only the shared asset types and exchanges are served
+// reliably.
+func common(sources []Source) ports.Capabilities {
+	if len(sources) == 0 {
+		return ports.Capabilities{}
+	}
+	c := ports.CapabilitiesOf(sources[0].Provider)
+	c.Batch, c.MaxBatchSize = false, 0
+	c.History, c.Intervals = false, nil
+	c.Streaming = false
+	for _, s := range sources[1:] {
+		sc := ports.CapabilitiesOf(s.Provider)
+		c.Volume = c.Volume && sc.Volume
+		c.Coverage = intersect(c.Coverage, sc.Coverage)
+	}
+	return c
+}
+
+func intersect(a, b domain.Coverage) domain.Coverage {
+	var out domain.Coverage
+	for _, t := range a.AssetTypes {
+		if slices.Contains(b.AssetTypes, t) {
+			out.AssetTypes = append(out.AssetTypes, t)
+		}
+	}
+	switch {
+	case len(a.Exchanges) == 0:
+		out.Exchanges = b.Exchanges
+	case len(b.Exchanges) == 0:
+		out.Exchanges = a.Exchanges
+	default:
+		for _, e := range a.Exchanges {
+			if slices.Contains(b.Exchanges, e) {
+				out.Exchanges = append(out.Exchanges, e)
+			}
+		}
+		if len(out.Exchanges) == 0 {
+			// An empty list would mean any exchange, so report no coverage instead
+			out.AssetTypes = nil
+		}
+	}
+	return out
+}
+
+func (l *LoggingDecorator) Capabilities() ports.Capabilities  { return forward(l.provider) }
+func (m *MetricsDecorator) Capabilities() ports.Capabilities  { return forward(m.provider) }
+func (t *TracingDecorator) Capabilities() ports.Capabilities  { return forward(t.provider) }
+func (cb *CircuitBreaker) Capabilities() ports.Capabilities   { return forward(cb.provider) }
+func (r *Retry) Capabilities() ports.Capabilities             { return forward(r.provider) }
+func (rl *RateLimit) Capabilities() ports.Capabilities        { return forward(rl.provider) }
+func (a *AdaptiveRateLimit) Capabilities() ports.Capabilities { return forward(a.provider) }
+func (c *Cache) Capabilities() ports.Capabilities             { return forward(c.provider) }
+func (c *Coalesce) Capabilities() ports.Capabilities          { return forward(c.provider) }
+func (b *Bulkhead) Capabilities() ports.Capabilities          { return forward(b.provider) }
+func (h *Hedge) Capabilities() ports.Capabilities             { return forward(h.provider) }
+func (t *Timeout) Capabilities() ports.Capabilities           { return forward(t.provider) }
+func (f *Failover) Capabilities() ports.Capabilities          { return common(f.sources) }
+func (c *Consensus) Capabilities() ports.Capabilities         { return common(c.sources) }
+
+// Capabilities adds streaming to the wrapped provider's capabilities
+func (p *Poller) Capabilities() ports.Capabilities {
+	c := forward(p.provider)
+	c.Streaming = true
+	return c
+}
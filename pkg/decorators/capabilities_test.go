@@ -0,0 +1,125 @@
+package decorators_test
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// capableProvider declares batch and history support for crypto assets
+type capableProvider struct {
+	MockProvider
+	coverage domain.Coverage
+}
+
+func (p *capableProvider) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	return nil, nil
+}
+
+func (p *capableProvider) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return nil, nil
+}
+
+func (p *capableProvider) Capabilities() ports.Capabilities {
+	return ports.Capabilities{
+		Batch:        true,
+		MaxBatchSize: 50,
+		History:      true,
+		Intervals:    []domain.Interval{domain.Interval1d},
+		Volume:       true,
+		Coverage:     p.coverage,
+	}
+}
+
+func TestCapabilitiesPropagate(t *testing.T) {
+	inner := &capableProvider{coverage: domain.Coverage{AssetTypes: []domain.AssetType{domain.AssetTypeCrypto}}}
+	limiter := decorators.NewLimiter(100, 1)
+	defer limiter.Close()
+	production, err := decorators.Preset("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	consensus, err := decorators.NewConsensus([]decorators.Source{{Name: "a", Provider: inner}, {Name: "b", Provider: inner}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped := map[string]ports.Provider{
+		"logging":   decorators.NewLoggingDecorator(inner, slog.Default(), "p"),
+		"metrics":   decorators.NewMetricsDecorator(inner, &MockCollector{}, "p"),
+		"tracing":   decorators.NewTracingDecorator(inner, nil, "p"),
+		"breaker":   decorators.NewCircuitBreaker(inner, 3, time.Second),
+		"retry":     decorators.NewRetry(inner, 3, time.Millisecond),
+		"adaptive":  decorators.NewAdaptiveRateLimit(inner, 10),
+		"cache":     decorators.NewCache(inner, 10, time.Minute),
+		"coalesce":  decorators.NewCoalesce(inner),
+		"bulkhead":  decorators.NewBulkhead(inner, 2),
+		"hedge":     decorators.NewHedge(inner, time.Second),
+		"timeout":   decorators.NewTimeout(inner, time.Second),
+		"poller":    decorators.NewPoller(inner, time.Second),
+		"ratelimit": decorators.NewSharedRateLimit(inner, limiter),
+		"chain":     production.Build(inner),
+		"failover":  decorators.NewFailover([]decorators.Source{{Name: "a", Provider: inner}, {Name: "b", Provider: inner}}),
+		"consensus": consensus,
+	}
+	for name, p := range wrapped {
+		cp, ok := p.(ports.CapabilityProvider)
+		if !ok {
+			t.Errorf("%s: does not implement ports.CapabilityProvider", name)
+			continue
+		}
+		c := cp.Capabilities()
+		if !c.Volume || !slices.Equal(c.Coverage.AssetTypes, inner.coverage.AssetTypes) {
+			t.Errorf("%s: capabilities not propagated: %+v", name, c)
+		}
+
+		// The As helpers agree with the declared capabilities
+		if _, batch := ports.AsBatchProvider(p); c.Batch != batch {
+			t.Errorf("%s: expected Batch %v, got %v", name, batch, c.Batch)
+		}
+		if _, history := ports.AsHistoricalProvider(p); c.History != history {
+			t.Errorf("%s: expected History %v, got %v", name, history, c.History)
+		}
+		if _, streaming := ports.AsStreamingProvider(p); c.Streaming != streaming {
+			t.Errorf("%s: expected Streaming %v, got %v", name, streaming, c.Streaming)
+		}
+	}
+
+	// Single-provider decorators, and the production preset chaining them, keep batching
+	// and history
+	for name, p := range wrapped {
+		if name == "failover" || name == "consensus" {
+			continue
+		}
+		c := p.(ports.CapabilityProvider).Capabilities()
+		if !c.Batch || c.MaxBatchSize != 50 || !c.History || !slices.Equal(c.Intervals, []domain.Interval{domain.Interval1d}) {
+			t.Errorf("%s: batch and history should survive wrapping: %+v", name, c)
+		}
+	}
+	if c := wrapped["chain"].(ports.CapabilityProvider).Capabilities(); !c.Batch || !c.History {
+		t.Errorf("production preset should keep batch and history: %+v", c)
+	}
+}
+
+func TestCapabilitiesCommonCoverage(t *testing.T) {
+	stocks := &capableProvider{coverage: domain.Coverage{
+		AssetTypes: []domain.AssetType{domain.AssetTypeStock, domain.AssetTypeCrypto},
+		Exchanges:  []string{"XNAS", "XLON"},
+	}}
+	nasdaq := &capableProvider{coverage: domain.Coverage{
+		AssetTypes: []domain.AssetType{domain.AssetTypeStock},
+		Exchanges:  []string{"XNAS"},
+	}}
+
+	f := decorators.NewFailover([]decorators.Source{{Name: "a", Provider: stocks}, {Name: "b", Provider: nasdaq}})
+	cov := f.Capabilities().Coverage
+	if !slices.Equal(cov.AssetTypes, []domain.AssetType{domain.AssetTypeStock}) || !slices.Equal(cov.Exchanges, []string{"XNAS"}) {
+		t.Errorf("expected stocks on XNAS only, got %+v", cov)
+	}
+}
@@ -0,0 +1,194 @@
+package decorators
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"markets-sdk/pkg/ports"
+)
+
+// Middleware wraps a provider with additional behaviour
+type Middleware func(ports.Provider) ports.Provider
+
+// Chain composes middleware into one. The first middleware is the outermost, so
+// Chain(a, b)(p) is a(b(p)).
+func Chain(mw ...Middleware) Middleware {
+	return func(p ports.Provider) ports.Provider {
+		for i := len(mw) - 1; i >= 0; i-- {
+			if mw[i] != nil {
+				p = mw[i](p)
+			}
+		}
+		return p
+	}
+}
+
+// Stage positions used by Builder, from outermost to innermost.
+//
+// Observability wraps everything so spans, logs and metrics cover the whole call.
+// Cache and Coalesce come next so hits and shared calls skip the resilience stack.
+// The CircuitBreaker sits outside Retry, so it checks every call before any attempt is
+// made and, once open, fails it fast on domain.ErrCircuitOpen without entering the retry
+// loop; a failure is recorded only when the retries are exhausted. Bulkhead and
+// RateLimit admit each attempt, and Timeout is innermost so it bounds a single attempt.
+const (
+	stageCustom = iota
+	stageTracing
+	stageLogging
+	stageMetrics
+	stageCache
+	stageCoalesce
+	stageHedge
+	stageCircuitBreaker
+	stageRetry
+	stageBulkhead
+	stageRateLimit
+	stageTimeout
+)
+
+type stage struct {
+	rank int
+	mw   Middleware
+}
+
+// Builder assembles a decorator stack in the recommended order regardless of the
+// order in which stages are added. Adding a built-in stage twice replaces it.
+type Builder struct {
+	stages []stage
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Preset returns a builder pre-configured for a named environment:
+//   - "production": coalescing, retries with jitter, a circuit breaker, a bulkhead and a 5s attempt timeout
+//   - "test": a 1s attempt timeout only, so tests fail fast and deterministically
+//
+// Observability and rate limits depend on the deployment and are added by the caller.
+func Preset(name string) (*Builder, error) {
+	switch name {
+	case "production":
+		return NewBuilder().
+			Coalesce().
+			Retry(3, 100*time.Millisecond, WithJitter(JitterFull), WithMaxDelay(2*time.Second)).
+			CircuitBreaker(5, 30*time.Second).
+			Bulkhead(64, WithQueue(128, time.Second)).
+			Timeout(5 * time.Second), nil
+	case "test":
+		return NewBuilder().Timeout(time.Second), nil
+	}
+	return nil, fmt.Errorf("unknown preset %q", name)
+}
+
+func (b *Builder) add(rank int, mw Middleware) *Builder {
+	if rank != stageCustom {
+		for i, s := range b.stages {
+			if s.rank == rank {
+				b.stages[i].mw = mw
+				return b
+			}
+		}
+	}
+	b.stages = append(b.stages, stage{rank: rank, mw: mw})
+	return b
+}
+
+// Use adds custom middleware outside every built-in stage. Custom middleware is
+// applied in the order added, the first being the outermost.
+func (b *Builder) Use(mw Middleware) *Builder {
+	return b.add(stageCustom, mw)
+}
+
+func (b *Builder) Tracing(tracer Tracer, name string) *Builder {
+	return b.add(stageTracing, func(p ports.Provider) ports.Provider {
+		return NewTracingDecorator(p, tracer, name)
+	})
+}
+
+func (b *Builder) Logging(logger *slog.Logger, name string) *Builder {
+	return b.add(stageLogging, func(p ports.Provider) ports.Provider {
+		return NewLoggingDecorator(p, logger, name)
+	})
+}
+
+func (b *Builder) Metrics(collector MetricsCollector, name string) *Builder {
+	return b.add(stageMetrics, func(p ports.Provider) ports.Provider {
+		return NewMetricsDecorator(p, collector, name)
+	})
+}
+
+func (b *Builder) Cache(size int, ttl time.Duration, opts ...CacheOption) *Builder {
+	return b.add(stageCache, func(p ports.Provider) ports.Provider {
+		return NewCache(p, size, ttl, opts...)
+	})
+}
+
+func (b *Builder) Coalesce(opts ...CoalesceOption) *Builder {
+	return b.add(stageCoalesce, func(p ports.Provider) ports.Provider {
+		return NewCoalesce(p, opts...)
+	})
+}
+
+func (b *Builder) Hedge(delay time.Duration, opts ...HedgeOption) *Builder {
+	return b.add(stageHedge, func(p ports.Provider) ports.Provider {
+		return NewHedge(p, delay, opts...)
+	})
+}
+
+func (b *Builder) Retry(maxRetries int, baseDelay time.Duration, opts ...RetryOption) *Builder {
+	return b.add(stageRetry, func(p ports.Provider) ports.Provider {
+		return NewRetry(p, maxRetries, baseDelay, opts...)
+	})
+}
+
+func (b *Builder) CircuitBreaker(failureThreshold int, resetTimeout time.Duration, opts ...CircuitBreakerOption) *Builder {
+	return b.add(stageCircuitBreaker, func(p ports.Provider) ports.Provider {
+		return NewCircuitBreaker(p, failureThreshold, resetTimeout, opts...)
+	})
+}
+
+func (b *Builder) Bulkhead(maxConcurrent int, opts ...BulkheadOption) *Builder {
+	return b.add(stageBulkhead, func(p ports.Provider) ports.Provider {
+		return NewBulkhead(p, maxConcurrent, opts...)
+	})
+}
+
+// RateLimit limits calls with limiter, which may be shared with other stacks
+func (b *Builder) RateLimit(limiter *Limiter, opts ...RateLimitOption) *Builder {
+	return b.add(stageRateLimit, func(p ports.Provider) ports.Provider {
+		return NewSharedRateLimit(p, limiter, opts...)
+	})
+}
+
+// AdaptiveRateLimit replaces a fixed RateLimit stage with an adaptive one
+func (b *Builder) AdaptiveRateLimit(rate float64, opts ...AdaptiveOption) *Builder {
+	return b.add(stageRateLimit, func(p ports.Provider) ports.Provider {
+		return NewAdaptiveRateLimit(p, rate, opts...)
+	})
+}
+
+func (b *Builder) Timeout(timeout time.Duration) *Builder {
+	return b.add(stageTimeout, func(p ports.Provider) ports.Provider {
+		return NewTimeout(p, timeout)
+	})
+}
+
+// Middleware returns the configured stack as a single middleware
+func (b *Builder) Middleware() Middleware {
+	stages := append([]stage(nil), b.stages...)
+	sort.SliceStable(stages, func(i, j int) bool { return stages[i].rank < stages[j].rank })
+
+	mw := make([]Middleware, len(stages))
+	for i, s := range stages {
+		mw[i] = s.mw
+	}
+	return Chain(mw...)
+}
+
+// Build wraps provider with the configured stack
+func (b *Builder) Build(provider ports.Provider) ports.Provider {
+	return b.Middleware()(provider)
+}
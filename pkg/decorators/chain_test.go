@@ -0,0 +1,172 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// recorder is a middleware that appends its name to a trace on every call
+func recorder(name string, trace *[]string) decorators.Middleware {
+	return func(p ports.Provider) ports.Provider {
+		return &MockProvider{
+			QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+				*trace = append(*trace, name)
+				return p.GetQuote(ctx, symbol)
+			},
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var trace []string
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			trace = append(trace, "provider")
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+
+	p := decorators.Chain(recorder("a", &trace), nil, recorder("b", &trace))(mock)
+	if _, err := p.GetQuote(context.Background(), "BTC"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"a", "b", "provider"}
+	if len(trace) != len(expected) || trace[0] != "a" || trace[1] != "b" {
+		t.Errorf("expected %v, got %v", expected, trace)
+	}
+}
+
+func TestBuilderOrder(t *testing.T) {
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			return nil, errors.New("down")
+		},
+	}
+
+	// Added in the "wrong" order: the builder still puts the breaker outside Retry,
+	// so it records one failure per exhausted retry loop and, once open, fails calls
+	// before any attempt is made
+	var trace []string
+	p := decorators.NewBuilder().
+		Retry(3, time.Millisecond).
+		CircuitBreaker(1, time.Minute).
+		Use(recorder("custom", &trace)).
+		Build(mock)
+
+	_, err := p.GetQuote(context.Background(), "BTC")
+	if errors.Is(err, domain.ErrCircuitOpen) {
+		t.Errorf("expected the upstream error once retries are exhausted, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 attempts, got %d", calls)
+	}
+
+	_, err = p.GetQuote(context.Background(), "BTC")
+	if !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected no attempt through the open breaker, got %d calls", calls)
+	}
+	if len(trace) != 2 {
+		t.Errorf("expected custom middleware to run once per call as the outermost stage, got %v", trace)
+	}
+}
+
+func TestPreset(t *testing.T) {
+	for _, name := range []string{"production", "test"} {
+		b, err := decorators.Preset(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", name, err)
+		}
+		p := b.Build(&MockProvider{
+			QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+				return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+			},
+		})
+		if q, err := p.GetQuote(context.Background(), "BTC"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(1)) {
+			t.Errorf("%s: unexpected result %v, %v", name, q, err)
+		}
+	}
+
+	if _, err := decorators.Preset("staging"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestPresetForwardsOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	var batches [][]string
+	attempts := 0
+	mock := &MockFullProvider{
+		QuotesFn: func(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+			batches = append(batches, symbols)
+			quotes := make(map[string]*domain.Quote)
+			failed := make(map[string]error)
+			for _, s := range symbols {
+				switch {
+				case s == "NOPE":
+					failed[s] = domain.ErrSymbolNotFound
+				case s == "FLAKY" && len(batches) == 1:
+					failed[s] = errors.New("temporary")
+				default:
+					quotes[s] = &domain.Quote{Symbol: s}
+				}
+			}
+			return quotes, &domain.BatchError{Errors: failed}
+		},
+		HistoryFn: func(ctx context.Context, symbol string) ([]domain.Candle, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errors.New("temporary")
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("expected the attempt to be bounded by the timeout stage")
+			}
+			return []domain.Candle{{Symbol: symbol}}, nil
+		},
+	}
+	production, err := decorators.Preset("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := production.Build(mock)
+
+	// 1. History is forwarded through every stage and retried
+	hp, ok := ports.AsHistoricalProvider(p)
+	if !ok {
+		t.Fatal("expected history through the production preset")
+	}
+	candles, err := hp.GetHistory(ctx, "BTC", domain.Interval1d, time.Now().Add(-time.Hour), time.Now())
+	if err != nil || len(candles) != 1 || attempts != 2 {
+		t.Errorf("expected candles after 2 attempts, got %v, %v after %d", candles, err, attempts)
+	}
+
+	// 2. Batches stay native and only retryable failures are asked for again
+	bp, ok := ports.AsBatchProvider(p)
+	if !ok {
+		t.Fatal("expected batching through the production preset")
+	}
+	quotes, err := bp.GetQuotes(ctx, []string{"BTC", "FLAKY", "NOPE"})
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || !errors.Is(batchErr.Errors["NOPE"], domain.ErrSymbolNotFound) {
+		t.Errorf("expected only NOPE to fail, got %v", err)
+	}
+	if len(quotes) != 2 || len(batches) != 2 || len(batches[1]) != 1 || batches[1][0] != "FLAKY" {
+		t.Errorf("expected FLAKY to be retried alone, got quotes %v and batches %v", quotes, batches)
+	}
+
+	// 3. Streaming is not invented
+	if _, ok := ports.AsStreamingProvider(p); ok {
+		t.Error("expected no streaming support")
+	}
+}
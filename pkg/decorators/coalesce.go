@@ -0,0 +1,155 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Coalesce is a decorator that collapses concurrent GetQuote calls for the same
+// symbol into a single upstream call (singleflight)
+type Coalesce struct {
+	provider ports.Provider
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is an upstream call shared by every caller waiting on the same symbol
+type flight struct {
+	done    chan struct{}
+	quote   *domain.Quote
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+	expired bool // the call ran out of the deadline it inherited from its first caller
+}
+
+// CoalesceOption configures a Coalesce
+type CoalesceOption func(*Coalesce)
+
+// WithCoalesceTimeout bounds every shared call to d instead of the deadline of the
+// caller that started it
+func WithCoalesceTimeout(d time.Duration) CoalesceOption {
+	return func(c *Coalesce) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+func NewCoalesce(provider ports.Provider, opts ...CoalesceOption) *Coalesce {
+	c := &Coalesce{
+		provider: provider,
+		flights:  make(map[string]*flight),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetQuote joins the call in flight for symbol or starts one. A shared call carries
+// the deadline of the caller that started it, or WithCoalesceTimeout; callers with
+// time left when it expires start a new call under their own deadline.
+func (c *Coalesce) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	for {
+		f := c.join(ctx, symbol)
+
+		select {
+		case <-f.done:
+			if f.expired && ctx.Err() == nil {
+				continue
+			}
+			if f.err != nil {
+				return nil, f.err
+			}
+			return f.quote.Clone(), nil
+		case <-ctx.Done():
+			c.leave(symbol, f)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// join registers the caller with the call in flight for symbol, starting it if needed
+func (c *Coalesce) join(ctx context.Context, symbol string) *flight {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.flights[symbol]
+	if !ok {
+		// The shared call must outlive any single caller, so it only keeps the values
+		// and the deadline of the first caller's context and is cancelled once nobody waits
+		var (
+			shared    = context.WithoutCancel(ctx)
+			callCtx   context.Context
+			cancel    context.CancelFunc
+			inherited bool
+		)
+		switch deadline, ok := ctx.Deadline(); {
+		case c.timeout > 0:
+			callCtx, cancel = context.WithTimeout(shared, c.timeout)
+		case ok:
+			callCtx, cancel = context.WithDeadline(shared, deadline)
+			inherited = true
+		default:
+			callCtx, cancel = context.WithCancel(shared)
+		}
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[symbol] = f
+		go c.do(callCtx, symbol, f, inherited)
+	}
+	f.waiters++
+	return f
+}
+
+// GetQuotes passes a native batch through and otherwise coalesces every symbol
+func (c *Coalesce) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	return getQuotes(ctx, c.provider, c.GetQuote, symbols)
+}
+
+func (c *Coalesce) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return getHistory(ctx, c.provider, symbol, interval, from, to)
+}
+
+func (c *Coalesce) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, c.provider, symbols)
+}
+
+func (c *Coalesce) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, c.provider, symbols)
+}
+
+func (c *Coalesce) do(ctx context.Context, symbol string, f *flight, inherited bool) {
+	f.quote, f.err = c.provider.GetQuote(ctx, symbol)
+	f.expired = inherited && f.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	f.cancel()
+
+	c.mu.Lock()
+	if c.flights[symbol] == f {
+		delete(c.flights, symbol)
+	}
+	c.mu.Unlock()
+
+	close(f.done)
+}
+
+// leave removes a caller that gave up, cancelling the upstream call if it was the last one
+func (c *Coalesce) leave(symbol string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f.waiters--
+	if f.waiters == 0 {
+		f.cancel()
+		if c.flights[symbol] == f {
+			delete(c.flights, symbol)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestCoalesce(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+	release := make(chan struct{})
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	c := decorators.NewCoalesce(mock)
+
+	const n = 50
+	quotes := make([]*domain.Quote, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q, err := c.GetQuote(ctx, "bitcoin")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			quotes[i] = q
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls)
+	}
+
+	// Each caller gets its own copy
+	quotes[0].Price = domain.NewDecimalFromInt(1)
+	if !quotes[1].Price.Equal(domain.NewDecimalFromInt(100)) {
+		t.Errorf("expected independent copies, got price %v", quotes[1].Price)
+	}
+}
+
+func TestCoalesceCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			select {
+			case <-release:
+				return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(100)}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+	c := decorators.NewCoalesce(mock)
+
+	// The first caller gives up early
+	cancelled, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.GetQuote(cancelled, "bitcoin")
+		errCh <- err
+	}()
+
+	// The second caller joins the same flight and must still get the result
+	resCh := make(chan *domain.Quote, 1)
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		q, _ := c.GetQuote(context.Background(), "bitcoin")
+		resCh <- q
+	}()
+
+	if err := <-errCh; err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	close(release)
+
+	select {
+	case q := <-resCh:
+		if q == nil || !q.Price.Equal(domain.NewDecimalFromInt(100)) {
+			t.Errorf("expected shared result, got %+v", q)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second caller did not receive a result")
+	}
+}
+
+func TestCoalesceDeadline(t *testing.T) {
+	var sawDeadline atomic.Bool
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			_, ok := ctx.Deadline()
+			sawDeadline.Store(ok)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	// 1. The shared call keeps the caller's deadline
+	c := decorators.NewCoalesce(mock)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetQuote(ctx, "bitcoin"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if !sawDeadline.Load() {
+		t.Error("expected the upstream call to carry the caller's deadline")
+	}
+
+	// 2. A configured timeout bounds calls whose callers have no deadline
+	c = decorators.NewCoalesce(mock, decorators.WithCoalesceTimeout(10*time.Millisecond))
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetQuote(context.Background(), "bitcoin")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shared call was not bounded by the coalesce timeout")
+	}
+}
@@ -0,0 +1,321 @@
+package decorators
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+// AggregationMethod selects how a Consensus combines the accepted prices
+type AggregationMethod string
+
+const (
+	AggregateMedian         AggregationMethod = "median"
+	AggregateMean           AggregationMethod = "mean"
+	AggregateVolumeWeighted AggregationMethod = "vwap"
+)
+
+// ConsensusError is returned when fewer sources than the quorum produced an acceptable price
+type ConsensusError struct {
+	Quorum   int
+	Accepted int
+	Errors   []*SourceError
+	Outliers []string
+}
+
+func (e *ConsensusError) Error() string {
+	msg := fmt.Sprintf("consensus not reached: %d of %d required sources agreed", e.Accepted, e.Quorum)
+	if len(e.Outliers) > 0 {
+		msg += fmt.Sprintf(" (outliers: %s)", strings.Join(e.Outliers, ", "))
+	}
+	if len(e.Errors) > 0 {
+		parts := make([]string, len(e.Errors))
+		for i, err := range e.Errors {
+			parts[i] = err.Error()
+		}
+		msg += ": " + strings.Join(parts, "; ")
+	}
+	return msg
+}
+
+// Unwrap allows errors.Is/As to inspect every underlying failure
+func (e *ConsensusError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Consensus is a provider that queries several sources concurrently and combines
+// their prices, rejecting sources that deviate too far from the median
+type Consensus struct {
+	sources   []Source
+	quorum    int
+	tolerance float64
+	maxWait   time.Duration
+	method    AggregationMethod
+	name      string
+}
+
+// ConsensusOption configures a Consensus
+type ConsensusOption func(*Consensus)
+
+// WithQuorum sets the minimum number of agreeing sources. Defaults to a majority.
+func WithQuorum(n int) ConsensusOption {
+	return func(c *Consensus) {
+		c.quorum = n
+	}
+}
+
+// WithTolerance flags sources whose price deviates from the median by more than
+// tolerance (relative, e.g. 0.01 for 1%). Zero disables outlier detection.
+func WithTolerance(tolerance float64) ConsensusOption {
+	return func(c *Consensus) {
+		c.tolerance = tolerance
+	}
+}
+
+// WithMaxWait stops waiting for slow sources after d as long as the quorum is met
+func WithMaxWait(d time.Duration) ConsensusOption {
+	return func(c *Consensus) {
+		c.maxWait = d
+	}
+}
+
+// WithAggregation sets how accepted prices are combined. Defaults to AggregateMedian.
+func WithAggregation(method AggregationMethod) ConsensusOption {
+	return func(c *Consensus) {
+		c.method = method
+	}
+}
+
+// WithConsensusName sets the Quote.Source of aggregated quotes. Defaults to "consensus".
+func WithConsensusName(name string) ConsensusOption {
+	return func(c *Consensus) {
+		c.name = name
+	}
+}
+
+// NewConsensus queries every source for each quote. Sources are told apart by name, so
+// every source needs a distinct, non-empty one.
+func NewConsensus(sources []Source, opts ...ConsensusOption) (*Consensus, error) {
+	seen := make(map[string]bool, len(sources))
+	for i, s := range sources {
+		if s.Name == "" {
+			return nil, fmt.Errorf("consensus: source %d has no name", i)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("consensus: duplicate source name %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	c := &Consensus{
+		sources: sources,
+		quorum:  len(sources)/2 + 1,
+		method:  AggregateMedian,
+		name:    "consensus",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+type sourceResult struct {
+	source string
+	quote  *domain.Quote
+	err    error
+}
+
+func (c *Consensus) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // abandons sources that have not answered yet
+
+	results := make(chan sourceResult, len(c.sources))
+	for _, s := range c.sources {
+		go func(s Source) {
+			q, err := s.Provider.GetQuote(ctx, symbol)
+			results <- sourceResult{source: s.Name, quote: q, err: err}
+		}(s)
+	}
+
+	var deadline <-chan time.Time
+	if c.maxWait > 0 {
+		timer := time.NewTimer(c.maxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var (
+		quotes   = make(map[string]*domain.Quote, len(c.sources))
+		failures []*SourceError
+	)
+collect:
+	for pending := len(c.sources); pending > 0; pending-- {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				failures = append(failures, &SourceError{Source: r.source, Err: r.err})
+				continue
+			}
+			quotes[r.source] = r.quote
+		case <-deadline:
+			// Outliers do not count towards the quorum
+			if accepted, _ := c.judge(quotes); len(accepted) >= c.quorum {
+				break collect
+			}
+			deadline = nil // keep waiting for the quorum until ctx ends
+			pending++
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return c.aggregate(symbol, quotes, failures)
+}
+
+// judge splits the sources that answered into accepted ones and outliers, sorted by name
+func (c *Consensus) judge(quotes map[string]*domain.Quote) (accepted, outliers []string) {
+	all := make([]domain.Decimal, 0, len(quotes))
+	for _, q := range quotes {
+		all = append(all, q.Price)
+	}
+	mid := median(all)
+
+	for name, q := range quotes {
+		if c.tolerance > 0 && !mid.IsZero() && q.Price.Sub(mid).Abs().Float64()/mid.Abs().Float64() > c.tolerance {
+			outliers = append(outliers, name)
+			continue
+		}
+		accepted = append(accepted, name)
+	}
+	sort.Strings(accepted)
+	sort.Strings(outliers)
+	return accepted, outliers
+}
+
+func (c *Consensus) aggregate(symbol string, quotes map[string]*domain.Quote, failures []*SourceError) (*domain.Quote, error) {
+	prices := make(map[string]domain.Decimal, len(quotes))
+	for name, q := range quotes {
+		prices[name] = q.Price
+	}
+	accepted, outliers := c.judge(quotes)
+
+	if len(accepted) == 0 || len(accepted) < c.quorum {
+		return nil, &ConsensusError{Quorum: c.quorum, Accepted: len(accepted), Errors: failures, Outliers: outliers}
+	}
+
+	var (
+		values  = make([]domain.Decimal, len(accepted))
+		changes = make([]domain.Decimal, len(accepted))
+		volumes = make([]domain.Decimal, len(accepted))
+		latest  time.Time
+	)
+	for i, name := range accepted {
+		q := quotes[name]
+		values[i], changes[i], volumes[i] = q.Price, q.Change24h, q.Volume
+		if q.LastUpdated.After(latest) {
+			latest = q.LastUpdated
+		}
+	}
+
+	var price domain.Decimal
+	switch c.method {
+	case AggregateMean:
+		price = mean(values)
+	case AggregateVolumeWeighted:
+		price = weightedMean(values, volumes)
+	default:
+		price = median(values)
+	}
+
+	return &domain.Quote{
+		Symbol:      symbol,
+		Price:       price,
+		Change24h:   median(changes),
+		Volume:      median(volumes),
+		LastUpdated: latest,
+		Source:      c.name,
+		Consensus: &domain.Consensus{
+			Method:     string(c.method),
+			Sources:    accepted,
+			Outliers:   outliers,
+			Prices:     prices,
+			Dispersion: relativeStdDev(values),
+		},
+	}, nil
+}
+
+// extraDigits is the precision added beyond the inputs' scale when averaging
+const extraDigits = 8
+
+var two = domain.NewDecimalFromInt(2)
+
+// median is exact: the mean of the two middle values needs one more digit at most
+func median(values []domain.Decimal) domain.Decimal {
+	if len(values) == 0 {
+		return domain.Decimal{}
+	}
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, domain.Decimal.Cmp)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	sum := sorted[n/2-1].Add(sorted[n/2])
+	return sum.Div(two, sum.Scale()+1, domain.RoundHalfEven)
+}
+
+func mean(values []domain.Decimal) domain.Decimal {
+	if len(values) == 0 {
+		return domain.Decimal{}
+	}
+	var sum domain.Decimal
+	for _, v := range values {
+		sum = sum.Add(v)
+	}
+	return sum.Div(domain.NewDecimalFromInt(int64(len(values))), sum.Scale()+extraDigits, domain.RoundHalfEven)
+}
+
+// weightedMean falls back to the plain mean when no weights are available
+func weightedMean(values, weights []domain.Decimal) domain.Decimal {
+	var sum, total domain.Decimal
+	for i, v := range values {
+		sum = sum.Add(v.Mul(weights[i]))
+		total = total.Add(weights[i])
+	}
+	if total.IsZero() {
+		return mean(values)
+	}
+	return sum.Div(total, sum.Scale()-total.Scale()+extraDigits, domain.RoundHalfEven)
+}
+
+// relativeStdDev is a statistic rather than an amount, so it is computed in float64
+func relativeStdDev(values []domain.Decimal) float64 {
+	fs := make([]float64, len(values))
+	var m float64
+	for i, v := range values {
+		fs[i] = v.Float64()
+		m += fs[i]
+	}
+	if len(fs) < 2 {
+		return 0
+	}
+	m /= float64(len(fs))
+	if m == 0 {
+		return 0
+	}
+	var sq float64
+	for _, f := range fs {
+		sq += (f - m) * (f - m)
+	}
+	return math.Sqrt(sq/float64(len(fs))) / math.Abs(m)
+}
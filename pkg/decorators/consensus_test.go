@@ -0,0 +1,162 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func priceSource(name string, price, volume int64, delay time.Duration) decorators.Source {
+	return decorators.Source{
+		Name: name,
+		Provider: &MockProvider{
+			QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(price), Volume: domain.NewDecimalFromInt(volume)}, nil
+			},
+		},
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 10, 0),
+		priceSource("b", 102, 30, 0),
+		priceSource("c", 150, 10, 0), // outlier
+	}, decorators.WithTolerance(0.05))
+
+	q, err := c.GetQuote(ctx, "bitcoin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.NewDecimalFromInt(101)) {
+		t.Errorf("expected median 101, got %v", q.Price)
+	}
+	if q.Source != "consensus" {
+		t.Errorf("expected source 'consensus', got %s", q.Source)
+	}
+	if len(q.Consensus.Sources) != 2 || len(q.Consensus.Outliers) != 1 || q.Consensus.Outliers[0] != "c" {
+		t.Errorf("unexpected consensus: %+v", q.Consensus)
+	}
+	if q.Consensus.Dispersion <= 0 {
+		t.Errorf("expected positive dispersion, got %v", q.Consensus.Dispersion)
+	}
+
+	// Volume-weighted price
+	c, err = decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 10, 0),
+		priceSource("b", 102, 30, 0),
+	}, decorators.WithAggregation(decorators.AggregateVolumeWeighted))
+	q, err = c.GetQuote(ctx, "bitcoin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.MustParseDecimal("101.5")) {
+		t.Errorf("expected vwap 101.5, got %v", q.Price)
+	}
+}
+
+func TestConsensusQuorum(t *testing.T) {
+	ctx := context.Background()
+	failing := func(name string) decorators.Source {
+		return decorators.Source{
+			Name: name,
+			Provider: &MockProvider{
+				QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+					return nil, errors.New("unavailable")
+				},
+			},
+		}
+	}
+
+	// 1. Slow source is dropped once the quorum is met and max wait elapsed
+	c, err := decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 0, 0),
+		priceSource("b", 100, 0, 0),
+		priceSource("slow", 100, 0, time.Second),
+	}, decorators.WithQuorum(2), decorators.WithMaxWait(20*time.Millisecond))
+
+	start := time.Now()
+	q, err := c.GetQuote(ctx, "bitcoin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected slow source to be abandoned")
+	}
+	if len(q.Consensus.Sources) != 2 {
+		t.Errorf("expected 2 contributing sources, got %v", q.Consensus.Sources)
+	}
+
+	// 2. Quorum not reached
+	c, err = decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 0, 0),
+		failing("down1"),
+		failing("down2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetQuote(ctx, "bitcoin")
+	var cErr *decorators.ConsensusError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected ConsensusError, got %v", err)
+	}
+	if cErr.Accepted != 1 || cErr.Quorum != 2 || len(cErr.Errors) != 2 {
+		t.Errorf("unexpected consensus error: %+v", cErr)
+	}
+}
+
+func TestConsensusCancelAndOutliers(t *testing.T) {
+	// 1. Cancelling the caller's context returns its error, not a ConsensusError
+	c, err := decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 0, time.Second),
+		priceSource("b", 100, 0, time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetQuote(ctx, "bitcoin"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// 2. An outlier does not count towards the quorum when max wait elapses
+	c, err = decorators.NewConsensus([]decorators.Source{
+		priceSource("a", 100, 0, 0),
+		priceSource("b", 100, 0, 0),
+		priceSource("c", 100, 0, 0),
+		priceSource("off", 300, 0, 0),
+		priceSource("slow", 100, 0, 50*time.Millisecond),
+	}, decorators.WithQuorum(4), decorators.WithTolerance(0.05), decorators.WithMaxWait(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := c.GetQuote(context.Background(), "bitcoin")
+	if err != nil {
+		t.Fatalf("expected the slow source to complete the quorum, got %v", err)
+	}
+	if len(q.Consensus.Sources) != 4 || len(q.Consensus.Outliers) != 1 {
+		t.Errorf("unexpected consensus: %+v", q.Consensus)
+	}
+}
+
+func TestConsensusSourceNames(t *testing.T) {
+	if _, err := decorators.NewConsensus([]decorators.Source{priceSource("a", 1, 0, 0), priceSource("a", 2, 0, 0)}); err == nil {
+		t.Error("expected duplicate source names to be rejected")
+	}
+	if _, err := decorators.NewConsensus([]decorators.Source{priceSource("", 1, 0, 0)}); err == nil {
+		t.Error("expected an empty source name to be rejected")
+	}
+}
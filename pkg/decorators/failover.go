@@ -0,0 +1,114 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Source is a named provider used by composite providers such as Failover
+type Source struct {
+	Name     string
+	Provider ports.Provider
+}
+
+// SourceError is the failure of a single source within a composite provider
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// FailoverError is returned when every source of a Failover failed
+type FailoverError struct {
+	Errors []*SourceError
+}
+
+func (e *FailoverError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = err.Error()
+	}
+	return fmt.Sprintf("all %d sources failed: %s", len(e.Errors), strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is/As to inspect every underlying failure
+func (e *FailoverError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// errNoQuote is the failure of a source returning neither a quote nor an error
+var errNoQuote = errors.New("provider returned no quote")
+
+// Failover is a provider that tries an ordered list of sources until one succeeds
+type Failover struct {
+	sources []Source
+	onSkip  func(source string, err error)
+}
+
+// FailoverOption configures a Failover
+type FailoverOption func(*Failover)
+
+// WithSkipHook calls fn every time a source fails and the next one is tried
+func WithSkipHook(fn func(source string, err error)) FailoverOption {
+	return func(f *Failover) {
+		f.onSkip = fn
+	}
+}
+
+func NewFailover(sources []Source, opts ...FailoverOption) *Failover {
+	f := &Failover{sources: sources}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// GetQuote returns the quote of the first source that succeeds, with Quote.Source
+// set to that source's name. Any error, including an open circuit breaker, moves on
+// to the next source, as does a source returning no quote; cancellation of ctx stops
+// immediately.
+func (f *Failover) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	failures := make([]*SourceError, 0, len(f.sources))
+
+	for _, s := range f.sources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		quote, err := s.Provider.GetQuote(ctx, symbol)
+		if err == nil && quote == nil {
+			err = errNoQuote
+		}
+		if err == nil {
+			// The source may share the quote, e.g. from a cache, so annotate a copy
+			quote = quote.Clone()
+			if s.Name != "" {
+				quote.Source = s.Name
+			}
+			return quote, nil
+		}
+
+		failures = append(failures, &SourceError{Source: s.Name, Err: err})
+		if f.onSkip != nil {
+			f.onSkip(s.Name, err)
+		}
+	}
+
+	return nil, &FailoverError{Errors: failures}
+}
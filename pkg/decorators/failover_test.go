@@ -0,0 +1,112 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("yahoo down")
+
+	primary := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return nil, errDown
+		},
+	}
+	secondary := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(100), Source: "mock"}, nil
+		},
+	}
+
+	var skipped []string
+	f := decorators.NewFailover([]decorators.Source{
+		{Name: "primary", Provider: primary},
+		{Name: "secondary", Provider: secondary},
+	}, decorators.WithSkipHook(func(source string, err error) {
+		skipped = append(skipped, source)
+	}))
+
+	// 1. Falls back to the secondary source
+	q, err := f.GetQuote(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Source != "secondary" {
+		t.Errorf("expected source 'secondary', got %s", q.Source)
+	}
+	if len(skipped) != 1 || skipped[0] != "primary" {
+		t.Errorf("expected primary to be skipped, got %v", skipped)
+	}
+
+	// 2. Open circuit breaker on the primary is skipped as well
+	cb := decorators.NewCircuitBreaker(primary, 1, time.Minute)
+	_, _ = cb.GetQuote(ctx, "AAPL")
+	f = decorators.NewFailover([]decorators.Source{
+		{Name: "primary", Provider: cb},
+		{Name: "secondary", Provider: secondary},
+	})
+	if q, err := f.GetQuote(ctx, "AAPL"); err != nil || q.Source != "secondary" {
+		t.Errorf("expected secondary after open breaker, got %v, %v", q, err)
+	}
+
+	// 3. All sources fail
+	f = decorators.NewFailover([]decorators.Source{
+		{Name: "a", Provider: primary},
+		{Name: "b", Provider: primary},
+	})
+	_, err = f.GetQuote(ctx, "AAPL")
+	var foErr *decorators.FailoverError
+	if !errors.As(err, &foErr) {
+		t.Fatalf("expected FailoverError, got %v", err)
+	}
+	if len(foErr.Errors) != 2 || foErr.Errors[1].Source != "b" {
+		t.Errorf("unexpected failures: %v", foErr.Errors)
+	}
+	if !errors.Is(err, errDown) {
+		t.Error("expected errors.Is to match the underlying error")
+	}
+}
+
+func TestFailoverDoesNotMutateQuotes(t *testing.T) {
+	ctx := context.Background()
+
+	shared := &domain.Quote{Symbol: "AAPL", Price: domain.NewDecimalFromInt(100), Source: "mock"}
+	empty := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return nil, nil
+		},
+	}
+	cached := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return shared, nil
+		},
+	}
+	f := decorators.NewFailover([]decorators.Source{
+		{Name: "empty", Provider: empty},
+		{Name: "cached", Provider: cached},
+	})
+
+	// A source returning no quote is skipped, and the returned quote is a copy
+	q, err := f.GetQuote(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Source != "cached" {
+		t.Errorf("expected source 'cached', got %s", q.Source)
+	}
+	if q == shared || shared.Source != "mock" {
+		t.Errorf("expected the source's quote to be left untouched, got %+v", shared)
+	}
+
+	f = decorators.NewFailover([]decorators.Source{{Name: "empty", Provider: empty}})
+	if _, err := f.GetQuote(ctx, "AAPL"); err == nil {
+		t.Error("expected an error when no source returns a quote")
+	}
+}
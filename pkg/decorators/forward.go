@@ -0,0 +1,108 @@
+package decorators
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Every decorator wrapping a single provider implements the optional ports interfaces
+// so that wrapping a provider never hides what it can do. Calls the wrapped provider
+// does not support fail with domain.ErrNotSupported, and Capabilities reports what it
+// does support. Streaming calls pass through untouched: retries, timeouts and rate
+// limits are meant for requests, not for subscriptions that live as long as their ctx.
+
+// fanOutConcurrency bounds the GetQuote calls made for a batch when the wrapped
+// provider cannot batch natively. It matches the client's default.
+const fanOutConcurrency = 8
+
+func notSupported(what string) error {
+	return fmt.Errorf("%w: wrapped provider does not support %s", domain.ErrNotSupported, what)
+}
+
+// getQuotes calls the native batch of p when it has one, and otherwise fans get out
+// over the symbols
+func getQuotes(ctx context.Context, p ports.Provider, get func(context.Context, string) (*domain.Quote, error), symbols []string) (map[string]*domain.Quote, error) {
+	if bp, ok := ports.AsBatchProvider(p); ok {
+		return bp.GetQuotes(ctx, symbols)
+	}
+	return fanOut(ctx, get, symbols)
+}
+
+// fanOut runs get for every symbol with bounded concurrency
+func fanOut(ctx context.Context, get func(context.Context, string) (*domain.Quote, error), symbols []string) (map[string]*domain.Quote, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		quotes = make(map[string]*domain.Quote, len(symbols))
+		failed = make(map[string]error)
+		sem    = make(chan struct{}, fanOutConcurrency)
+	)
+	for _, symbol := range symbols {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			failed[symbol] = ctx.Err()
+			mu.Unlock()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			q, err := get(ctx, symbol)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[symbol] = err
+				return
+			}
+			quotes[symbol] = q
+		}(symbol)
+	}
+	wg.Wait()
+
+	return batchResult(quotes, failed)
+}
+
+// batchResult reports failed symbols through a *domain.BatchError next to the quotes
+func batchResult(quotes map[string]*domain.Quote, failed map[string]error) (map[string]*domain.Quote, error) {
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+// getHistory calls the wrapped provider's GetHistory
+func getHistory(ctx context.Context, p ports.Provider, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(p)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	return hp.GetHistory(ctx, symbol, interval, from, to)
+}
+
+// subscribe and unsubscribe pass streaming calls through to the wrapped provider
+func subscribe(ctx context.Context, p ports.Provider, symbols []string) (<-chan domain.Quote, error) {
+	sp, ok := ports.AsStreamingProvider(p)
+	if !ok {
+		return nil, notSupported("streaming")
+	}
+	return sp.Subscribe(ctx, symbols)
+}
+
+func unsubscribe(ctx context.Context, p ports.Provider, symbols []string) error {
+	sp, ok := ports.AsStreamingProvider(p)
+	if !ok {
+		return notSupported("streaming")
+	}
+	return sp.Unsubscribe(ctx, symbols)
+}
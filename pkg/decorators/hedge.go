@@ -0,0 +1,201 @@
+package decorators
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+const (
+	// hedgeSamples is the number of recent latencies used to compute the hedge delay
+	hedgeSamples = 100
+	// hedgeMinSamples is how many latencies are needed before the percentile is trusted
+	hedgeMinSamples = 20
+	// hedgeMaxTokens bounds how many hedges can be saved up for a burst of slow calls
+	hedgeMaxTokens = 10
+)
+
+// Hedge is a decorator that reduces tail latency: when a call has not returned within
+// the configured latency percentile, a second call is sent (to the same provider or
+// an alternate one) and the first success wins while the other is cancelled
+type Hedge struct {
+	provider   ports.Provider
+	alternate  ports.Provider
+	delay      time.Duration
+	percentile float64
+	maxRatio   float64
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	tokens  float64
+}
+
+// HedgeOption configures a Hedge
+type HedgeOption func(*Hedge)
+
+// WithHedgePercentile hedges calls slower than the given latency percentile (0-1] of
+// recent successful calls. Values outside the range, such as 95 for 0.95, are
+// ignored. Defaults to 0.95.
+func WithHedgePercentile(p float64) HedgeOption {
+	return func(h *Hedge) {
+		if p > 0 && p <= 1 {
+			h.percentile = p
+		}
+	}
+}
+
+// WithAlternate sends hedged calls to provider instead of the wrapped one
+func WithAlternate(provider ports.Provider) HedgeOption {
+	return func(h *Hedge) {
+		h.alternate = provider
+	}
+}
+
+// WithMaxHedgeRatio caps hedged calls to ratio (0-1) of all calls. Defaults to 0.1.
+func WithMaxHedgeRatio(ratio float64) HedgeOption {
+	return func(h *Hedge) {
+		h.maxRatio = ratio
+	}
+}
+
+// NewHedge uses delay as the hedge delay until enough latencies have been observed
+// to compute the percentile
+func NewHedge(provider ports.Provider, delay time.Duration, opts ...HedgeOption) *Hedge {
+	h := &Hedge{
+		provider:   provider,
+		alternate:  provider,
+		delay:      delay,
+		percentile: 0.95,
+		maxRatio:   0.1,
+		samples:    make([]time.Duration, 0, hedgeSamples),
+		tokens:     1,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type hedgeResult struct {
+	quote *domain.Quote
+	err   error
+}
+
+func (h *Hedge) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancels the losing call
+
+	results := make(chan hedgeResult, 2)
+	call := func(p ports.Provider) {
+		q, err := p.GetQuote(ctx, symbol)
+		results <- hedgeResult{quote: q, err: err}
+	}
+
+	h.earn()
+	start := time.Now()
+	go call(h.provider)
+
+	timer := time.NewTimer(h.hedgeDelay())
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	var firstErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// The samples are primary latencies from the start of the call. When the
+				// hedge wins, the primary would have taken at least this long.
+				h.observe(time.Since(start))
+				return r.quote, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			// Hedging is for latency, not errors: a fast failure is returned as is
+			if pending == 0 {
+				return nil, firstErr
+			}
+		case <-timer.C:
+			if !hedged && h.spend() {
+				hedged = true
+				pending++
+				go call(h.alternate)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// GetQuotes passes a native batch through and otherwise hedges every symbol
+func (h *Hedge) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	return getQuotes(ctx, h.provider, h.GetQuote, symbols)
+}
+
+func (h *Hedge) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return getHistory(ctx, h.provider, symbol, interval, from, to)
+}
+
+func (h *Hedge) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, h.provider, symbols)
+}
+
+func (h *Hedge) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, h.provider, symbols)
+}
+
+// Delay returns the delay after which a call is currently hedged
+func (h *Hedge) Delay() time.Duration {
+	return h.hedgeDelay()
+}
+
+func (h *Hedge) hedgeDelay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < hedgeMinSamples {
+		return h.delay
+	}
+	sorted := append([]time.Duration(nil), h.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(h.percentile*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+func (h *Hedge) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < hedgeSamples {
+		h.samples = append(h.samples, latency)
+		return
+	}
+	h.samples[h.next] = latency
+	h.next = (h.next + 1) % hedgeSamples
+}
+
+// earn credits every call with maxRatio hedge tokens
+func (h *Hedge) earn() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens = math.Min(hedgeMaxTokens, h.tokens+h.maxRatio)
+}
+
+// spend takes a token for a hedged call if one is available
+func (h *Hedge) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
@@ -0,0 +1,128 @@
+package decorators_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+	cancelled := make(chan struct{}, 1)
+	slow := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			atomic.AddInt32(&calls, 1)
+			select {
+			case <-time.After(time.Second):
+				return &domain.Quote{Price: domain.NewDecimalFromInt(1), Source: "slow"}, nil
+			case <-ctx.Done():
+				cancelled <- struct{}{}
+				return nil, ctx.Err()
+			}
+		},
+	}
+	fast := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Price: domain.NewDecimalFromInt(2), Source: "fast"}, nil
+		},
+	}
+	h := decorators.NewHedge(slow, 10*time.Millisecond, decorators.WithAlternate(fast), decorators.WithMaxHedgeRatio(0.3))
+
+	// 1. The hedge answers first and the slow call is cancelled
+	start := time.Now()
+	q, err := h.GetQuote(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Source != "fast" {
+		t.Errorf("expected hedged result, got %s", q.Source)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected hedge to cut the latency")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected losing call to be cancelled")
+	}
+
+	// 2. The hedge budget is spent, so the next call waits for the primary
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := h.GetQuote(timeout, "AAPL"); err == nil {
+		t.Error("expected the unhedged slow call to hit the deadline")
+	}
+}
+
+func TestHedgePercentileDelay(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			time.Sleep(2 * time.Millisecond)
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	h := decorators.NewHedge(mock, time.Second)
+
+	if h.Delay() != time.Second {
+		t.Errorf("expected fallback delay before samples, got %v", h.Delay())
+	}
+	for i := 0; i < 25; i++ {
+		_, _ = h.GetQuote(ctx, "AAPL")
+	}
+	if d := h.Delay(); d < 2*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("expected delay near observed latency, got %v", d)
+	}
+}
+
+func TestHedgePercentileBounds(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+
+	// 95 instead of 0.95 is ignored rather than indexing past the samples
+	for _, p := range []float64{95, 0, -1, 1} {
+		h := decorators.NewHedge(mock, time.Second, decorators.WithHedgePercentile(p))
+		for i := 0; i < 25; i++ {
+			_, _ = h.GetQuote(ctx, "AAPL")
+		}
+		if d := h.Delay(); d >= time.Second {
+			t.Errorf("percentile %v: expected delay from samples, got %v", p, d)
+		}
+	}
+}
+
+func TestHedgeObservesPrimaryLatency(t *testing.T) {
+	ctx := context.Background()
+	slow := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	fast := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	h := decorators.NewHedge(slow, 5*time.Millisecond, decorators.WithAlternate(fast), decorators.WithMaxHedgeRatio(1))
+
+	// Hedged wins are recorded from the start of the call, not from the hedge's start
+	for i := 0; i < 25; i++ {
+		if _, err := h.GetQuote(ctx, "AAPL"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if d := h.Delay(); d < 5*time.Millisecond {
+		t.Errorf("expected delay of at least the hedge delay, got %v", d)
+	}
+}
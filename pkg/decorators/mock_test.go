@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"markets-sdk/pkg/domain"
+	"sync"
+	"time"
 )
 
 // MockProvider is a helper for testing decorators
@@ -17,3 +19,57 @@ func (m *MockProvider) GetQuote(ctx context.Context, symbol string) (*domain.Quo
 	}
 	return nil, errors.New("not implemented")
 }
+
+// MockCollector records metrics reported by decorators
+type MockCollector struct {
+	mu       sync.Mutex
+	Requests map[string]int     // keyed by status
+	Gauges   map[string]float64 // keyed by gauge name
+}
+
+func (m *MockCollector) IncRequest(provider, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Requests == nil {
+		m.Requests = make(map[string]int)
+	}
+	m.Requests[status]++
+}
+
+func (m *MockCollector) ObserveDuration(provider string, duration float64) {}
+
+func (m *MockCollector) Count(status string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Requests[status]
+}
+
+func (m *MockCollector) SetGauge(provider, name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Gauges == nil {
+		m.Gauges = make(map[string]float64)
+	}
+	m.Gauges[name] = value
+}
+
+func (m *MockCollector) Gauge(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Gauges[name]
+}
+
+// MockFullProvider adds history and batching to MockProvider
+type MockFullProvider struct {
+	MockProvider
+	QuotesFn  func(ctx context.Context, symbols []string) (map[string]*domain.Quote, error)
+	HistoryFn func(ctx context.Context, symbol string) ([]domain.Candle, error)
+}
+
+func (m *MockFullProvider) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	return m.QuotesFn(ctx, symbols)
+}
+
+func (m *MockFullProvider) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return m.HistoryFn(ctx, symbol)
+}
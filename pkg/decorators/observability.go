@@ -53,12 +53,67 @@ func (l *LoggingDecorator) GetQuote(ctx context.Context, symbol string) (*domain
 	return quote, nil
 }
 
+func (l *LoggingDecorator) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(l.provider)
+	if !ok {
+		return fanOut(ctx, l.GetQuote, symbols)
+	}
+	return logged(l, "quotes", []any{"symbols", len(symbols)}, func() (map[string]*domain.Quote, error) {
+		return bp.GetQuotes(ctx, symbols)
+	})
+}
+
+func (l *LoggingDecorator) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return logged(l, "history", []any{"symbol", symbol, "interval", interval}, func() ([]domain.Candle, error) {
+		return getHistory(ctx, l.provider, symbol, interval, from, to)
+	})
+}
+
+func (l *LoggingDecorator) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, l.provider, symbols)
+}
+
+func (l *LoggingDecorator) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, l.provider, symbols)
+}
+
+// logged wraps fn in the log lines GetQuote writes for a single quote
+func logged[T any](l *LoggingDecorator, what string, attrs []any, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	attrs = append([]any{"provider", l.providerName}, attrs...)
+
+	l.logger.Info("fetching "+what, attrs...)
+
+	v, err := fn()
+
+	attrs = append(attrs, "duration", time.Since(start))
+	if err != nil {
+		l.logger.Error("failed to fetch "+what, append(attrs, "error", err)...)
+		return v, err
+	}
+	l.logger.Info("fetched "+what, attrs...)
+	return v, nil
+}
+
 // MetricsCollector defines an interface for collecting metrics, allowing Prometheus or other backends
 type MetricsCollector interface {
 	IncRequest(provider, status string)
 	ObserveDuration(provider string, duration float64)
 }
 
+// GaugeCollector is optionally implemented by a MetricsCollector to receive point-in-time
+// values such as the current rate limit or the number of in-flight calls
+type GaugeCollector interface {
+	SetGauge(provider, name string, value float64)
+}
+
+// setGauge reports a gauge if the collector supports it
+func setGauge(collector MetricsCollector, provider, name string, value float64) {
+	if g, ok := collector.(GaugeCollector); ok {
+		g.SetGauge(provider, name, value)
+	}
+}
+
 // MetricsDecorator captures metrics for requests
 type MetricsDecorator struct {
 	provider  ports.Provider
@@ -75,8 +130,39 @@ func NewMetricsDecorator(provider ports.Provider, collector MetricsCollector, na
 }
 
 func (m *MetricsDecorator) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return measured(m, func() (*domain.Quote, error) {
+		return m.provider.GetQuote(ctx, symbol)
+	})
+}
+
+func (m *MetricsDecorator) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(m.provider)
+	if !ok {
+		return fanOut(ctx, m.GetQuote, symbols)
+	}
+	return measured(m, func() (map[string]*domain.Quote, error) {
+		return bp.GetQuotes(ctx, symbols)
+	})
+}
+
+func (m *MetricsDecorator) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return measured(m, func() ([]domain.Candle, error) {
+		return getHistory(ctx, m.provider, symbol, interval, from, to)
+	})
+}
+
+func (m *MetricsDecorator) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, m.provider, symbols)
+}
+
+func (m *MetricsDecorator) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, m.provider, symbols)
+}
+
+// measured counts fn as one request and observes its duration
+func measured[T any](m *MetricsDecorator, fn func() (T, error)) (T, error) {
 	start := time.Now()
-	quote, err := m.provider.GetQuote(ctx, symbol)
+	v, err := fn()
 	duration := time.Since(start).Seconds()
 
 	status := "success"
@@ -89,7 +175,7 @@ func (m *MetricsDecorator) GetQuote(ctx context.Context, symbol string) (*domain
 		m.collector.ObserveDuration(m.name, duration)
 	}
 
-	return quote, err
+	return v, err
 }
 
 // Tracer defines a minimal interface for distributed tracing (compatible with OpenTelemetry)
@@ -118,16 +204,47 @@ func NewTracingDecorator(provider ports.Provider, tracer Tracer, name string) *T
 }
 
 func (t *TracingDecorator) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
-	if t.tracer == nil {
+	return traced(ctx, t, "GetQuote", func(ctx context.Context) (*domain.Quote, error) {
 		return t.provider.GetQuote(ctx, symbol)
+	})
+}
+
+func (t *TracingDecorator) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(t.provider)
+	if !ok {
+		return fanOut(ctx, t.GetQuote, symbols)
+	}
+	return traced(ctx, t, "GetQuotes", func(ctx context.Context) (map[string]*domain.Quote, error) {
+		return bp.GetQuotes(ctx, symbols)
+	})
+}
+
+func (t *TracingDecorator) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return traced(ctx, t, "GetHistory", func(ctx context.Context) ([]domain.Candle, error) {
+		return getHistory(ctx, t.provider, symbol, interval, from, to)
+	})
+}
+
+func (t *TracingDecorator) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, t.provider, symbols)
+}
+
+func (t *TracingDecorator) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, t.provider, symbols)
+}
+
+// traced runs fn in a span named after the operation and the provider
+func traced[T any](ctx context.Context, t *TracingDecorator, op string, fn func(context.Context) (T, error)) (T, error) {
+	if t.tracer == nil {
+		return fn(ctx)
 	}
 
-	ctx, span := t.tracer.Start(ctx, "GetQuote/"+t.name)
+	ctx, span := t.tracer.Start(ctx, op+"/"+t.name)
 	defer span.End()
 
-	quote, err := t.provider.GetQuote(ctx, symbol)
+	v, err := fn(ctx)
 	if err != nil {
 		span.RecordError(err)
 	}
-	return quote, err
+	return v, err
 }
@@ -0,0 +1,213 @@
+package decorators
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Poller adapts a request/response provider into a ports.StreamingProvider by
+// polling GetQuote on a schedule and emitting quotes whose price or volume changed
+type Poller struct {
+	provider  ports.Provider
+	interval  time.Duration
+	onError   func(symbol string, err error)
+	collector MetricsCollector
+	name      string
+
+	mu   sync.Mutex
+	subs map[*pollSubscription]struct{}
+	seq  uint64
+}
+
+type pollSubscription struct {
+	ctx context.Context
+	seq uint64
+	out chan domain.Quote
+
+	mu      sync.Mutex
+	symbols []string
+	next    int
+}
+
+const defaultPollInterval = time.Second
+
+// PollerOption configures a Poller
+type PollerOption func(*Poller)
+
+// WithPollErrorHandler sets a function called with every failed poll
+func WithPollErrorHandler(fn func(symbol string, err error)) PollerOption {
+	return func(p *Poller) {
+		p.onError = fn
+	}
+}
+
+// WithPollerMetrics reports every poll as a request with status "success" or "error"
+func WithPollerMetrics(collector MetricsCollector, name string) PollerOption {
+	return func(p *Poller) {
+		p.collector = collector
+		p.name = name
+	}
+}
+
+// NewPoller polls every subscribed symbol once per interval, one second if interval is
+// not positive. Symbols of a subscription are polled one at a time, evenly spread across
+// the interval, to smooth upstream load.
+func NewPoller(provider ports.Provider, interval time.Duration, opts ...PollerOption) *Poller {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	p := &Poller{
+		provider: provider,
+		interval: interval,
+		subs:     make(map[*pollSubscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Poller) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return p.provider.GetQuote(ctx, symbol)
+}
+
+func (p *Poller) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	return getQuotes(ctx, p.provider, p.GetQuote, symbols)
+}
+
+func (p *Poller) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return getHistory(ctx, p.provider, symbol, interval, from, to)
+}
+
+// Subscribe starts polling symbols. Poll errors are reported to the WithPollErrorHandler
+// function and the symbol is retried on its next turn. The channel is closed when ctx
+// ends or every symbol is unsubscribed.
+func (p *Poller) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	sub := &pollSubscription{
+		ctx:     ctx,
+		out:     make(chan domain.Quote),
+		symbols: append([]string(nil), symbols...),
+	}
+
+	p.mu.Lock()
+	p.seq++
+	sub.seq = p.seq
+	p.subs[sub] = struct{}{}
+	p.mu.Unlock()
+
+	go p.run(ctx, sub)
+	return sub.out, nil
+}
+
+// Unsubscribe undoes one Subscribe of each symbol: the symbol stops being polled by
+// the latest active subscription that polls it, and keeps being polled for any other
+// subscriber. Subscriptions whose ctx has ended are already stopping and are left alone.
+func (p *Poller) Unsubscribe(ctx context.Context, symbols []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, symbol := range symbols {
+		var latest *pollSubscription
+		for sub := range p.subs {
+			if sub.ctx.Err() == nil && sub.has(symbol) && (latest == nil || sub.seq > latest.seq) {
+				latest = sub
+			}
+		}
+		if latest != nil {
+			latest.remove(symbol)
+		}
+	}
+	return nil
+}
+
+func (p *Poller) run(ctx context.Context, sub *pollSubscription) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.subs, sub)
+		p.mu.Unlock()
+		close(sub.out)
+	}()
+
+	last := make(map[string]domain.Quote)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		symbol, step, ok := sub.advance(p.interval)
+		if !ok {
+			return
+		}
+		timer.Reset(step)
+
+		quote, err := p.provider.GetQuote(ctx, symbol)
+		p.report(symbol, err)
+		if err != nil {
+			continue
+		}
+
+		prev, seen := last[symbol]
+		if seen && prev.Price.Equal(quote.Price) && prev.Volume.Equal(quote.Volume) {
+			continue
+		}
+		last[symbol] = *quote
+
+		select {
+		case sub.out <- *quote:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// report counts a poll and hands failures to the error handler
+func (p *Poller) report(symbol string, err error) {
+	if p.collector != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		p.collector.IncRequest(p.name, status)
+	}
+	if err != nil && p.onError != nil {
+		p.onError(symbol, err)
+	}
+}
+
+func (s *pollSubscription) has(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.symbols, symbol)
+}
+
+func (s *pollSubscription) remove(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = slices.DeleteFunc(s.symbols, func(v string) bool { return v == symbol })
+}
+
+// advance returns the next symbol to poll and the delay until the following poll
+func (s *pollSubscription) advance(interval time.Duration) (string, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.symbols) == 0 {
+		return "", 0, false
+	}
+	if s.next >= len(s.symbols) {
+		s.next = 0
+	}
+	symbol := s.symbols[s.next]
+	s.next++
+	return symbol, interval / time.Duration(len(s.symbols)), true
+}
@@ -0,0 +1,174 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+var _ ports.StreamingProvider = (*decorators.Poller)(nil)
+
+func TestPoller(t *testing.T) {
+	var price int64 = 100
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(atomic.LoadInt64(&price))}, nil
+		},
+	}
+	p := decorators.NewPoller(mock, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Subscribe(ctx, []string{"BTC", "ETH"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 1. First poll of each symbol is emitted
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case q := <-ch:
+			seen[q.Symbol] = true
+		case <-time.After(time.Second):
+			t.Fatal("expected initial quotes")
+		}
+	}
+
+	// 2. Unchanged quotes are suppressed
+	select {
+	case q := <-ch:
+		t.Fatalf("unexpected quote without change: %+v", q)
+	case <-time.After(40 * time.Millisecond):
+	}
+
+	// 3. Changes are emitted
+	atomic.StoreInt64(&price, 101)
+	select {
+	case q := <-ch:
+		if !q.Price.Equal(domain.NewDecimalFromInt(101)) {
+			t.Errorf("expected price 101, got %v", q.Price)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected changed quote")
+	}
+
+	// 4. Channel closes when the context ends
+	cancel()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+}
+
+func TestPollerUnsubscribe(t *testing.T) {
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+	p := decorators.NewPoller(mock, 5*time.Millisecond)
+
+	ch, _ := p.Subscribe(context.Background(), []string{"BTC"})
+	<-ch
+	_ = p.Unsubscribe(context.Background(), []string{"BTC"})
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected channel to close after unsubscribing every symbol")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after unsubscribe")
+	}
+}
+
+func TestPollerUnsubscribeScoped(t *testing.T) {
+	var n atomic.Int64
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Symbol: symbol, Price: domain.NewDecimalFromInt(n.Add(1))}, nil
+		},
+	}
+	p := decorators.NewPoller(mock, 5*time.Millisecond)
+
+	first, _ := p.Subscribe(context.Background(), []string{"BTC"})
+	second, _ := p.Subscribe(context.Background(), []string{"BTC"})
+	<-first
+	<-second
+
+	// Unsubscribing once ends one subscription, the other keeps polling
+	_ = p.Unsubscribe(context.Background(), []string{"BTC"})
+	select {
+	case _, ok := <-second:
+		for ok {
+			_, ok = <-second
+		}
+	case <-time.After(time.Second):
+		t.Fatal("latest subscription not closed after unsubscribe")
+	}
+	for range 3 {
+		select {
+		case _, ok := <-first:
+			if !ok {
+				t.Fatal("expected the other subscription to keep polling")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no quote from the other subscription")
+		}
+	}
+}
+
+func TestPollerReportsErrors(t *testing.T) {
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return nil, domain.ErrUpstreamUnavailable
+		},
+	}
+	collector := &MockCollector{}
+	failed := make(chan string, 1)
+	p := decorators.NewPoller(mock, 0,
+		decorators.WithPollerMetrics(collector, "poll"),
+		decorators.WithPollErrorHandler(func(symbol string, err error) {
+			if errors.Is(err, domain.ErrUpstreamUnavailable) {
+				select {
+				case failed <- symbol:
+				default:
+				}
+			}
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, _ = p.Subscribe(ctx, []string{"BTC"})
+
+	select {
+	case symbol := <-failed:
+		if symbol != "BTC" {
+			t.Errorf("expected BTC, got %s", symbol)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("poll error not reported")
+	}
+
+	// A zero interval falls back to the default instead of polling in a loop
+	time.Sleep(50 * time.Millisecond)
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if n := collector.Requests["error"]; n != 1 {
+		t.Errorf("expected 1 failed poll, got %d", n)
+	}
+}
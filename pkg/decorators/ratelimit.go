@@ -0,0 +1,245 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// ErrLimiterClosed is returned by calls waiting on a limiter that has been closed
+var ErrLimiterClosed = errors.New("rate limiter closed")
+
+// Limiter is a token bucket refilled lazily on each call, so it needs no background
+// goroutine. A single Limiter can be shared by every decorator calling the same upstream.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	closed bool
+	done   chan struct{}
+}
+
+// Per converts "n requests per d" into a rate in tokens per second, e.g. Per(30, time.Minute)
+func Per(n int, d time.Duration) float64 {
+	return float64(n) / d.Seconds()
+}
+
+// NewLimiter creates a full bucket allowing rate tokens per second (may be fractional)
+// and bursts of up to burst tokens
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		done:   make(chan struct{}),
+	}
+}
+
+// Wait blocks until cost tokens are available, ctx ends or the limiter is closed
+func (l *Limiter) Wait(ctx context.Context, cost float64) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrLimiterClosed
+	}
+	if cost > l.burst {
+		l.mu.Unlock()
+		return fmt.Errorf("cost %.2f exceeds limiter burst %.0f", cost, l.burst)
+	}
+
+	now := time.Now()
+	l.refill(now)
+
+	// Reserve the tokens up front, going into debt if needed, so waiters are
+	// served in arrival order
+	l.tokens -= cost
+	var wait time.Duration
+	if l.tokens < 0 {
+		if l.rate <= 0 {
+			l.tokens += cost
+			l.mu.Unlock()
+			return fmt.Errorf("limiter has no refill rate")
+		}
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.refund(cost)
+		return ctx.Err()
+	case <-l.done:
+		return ErrLimiterClosed
+	}
+}
+
+// Allow takes cost tokens if they are available right now
+func (l *Limiter) Allow(cost float64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return false
+	}
+	l.refill(time.Now())
+	if l.tokens < cost {
+		return false
+	}
+	l.tokens -= cost
+	return true
+}
+
+// Rate returns the current refill rate in tokens per second
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// SetRate changes the refill rate. Tokens accrued so far are kept.
+func (l *Limiter) SetRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.rate = rate
+}
+
+// Close releases every waiter with ErrLimiterClosed and rejects further calls
+func (l *Limiter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.done)
+	}
+	return nil
+}
+
+// refill adds the tokens accrued since the last call. Must hold l.mu.
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	}
+}
+
+func (l *Limiter) refund(cost float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens = math.Min(l.burst, l.tokens+cost)
+}
+
+// RateLimit is a decorator that implements token bucket rate limiting
+type RateLimit struct {
+	provider  ports.Provider
+	limiter   *Limiter
+	owned     bool // the limiter was created by the decorator and is closed with it
+	batchCost func(n int) float64
+}
+
+// RateLimitOption configures a RateLimit
+type RateLimitOption func(*RateLimit)
+
+// WithBatchCost sets how many tokens a GetQuotes call for n symbols costs.
+// Defaults to one token per batch request.
+func WithBatchCost(fn func(n int) float64) RateLimitOption {
+	return func(rl *RateLimit) {
+		rl.batchCost = fn
+	}
+}
+
+// NewRateLimit allows rps requests per second with bursts of up to rps requests
+func NewRateLimit(provider ports.Provider, rps int, opts ...RateLimitOption) *RateLimit {
+	rl := NewSharedRateLimit(provider, NewLimiter(float64(rps), rps), opts...)
+	rl.owned = true
+	return rl
+}
+
+// NewSharedRateLimit limits provider with an existing limiter, typically shared by every
+// provider that calls the same upstream host. Closing the decorator leaves the limiter open.
+func NewSharedRateLimit(provider ports.Provider, limiter *Limiter, opts ...RateLimitOption) *RateLimit {
+	rl := &RateLimit{
+		provider:  provider,
+		limiter:   limiter,
+		batchCost: func(int) float64 { return 1 },
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+	return rl
+}
+
+func (rl *RateLimit) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	if err := rl.limiter.Wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	return rl.provider.GetQuote(ctx, symbol)
+}
+
+// GetQuotes charges the batch cost once when the wrapped provider supports batching,
+// and otherwise fans GetQuote out with bounded concurrency, one token per symbol
+func (rl *RateLimit) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	if bp, ok := ports.AsBatchProvider(rl.provider); ok {
+		if err := rl.limiter.Wait(ctx, rl.batchCost(len(symbols))); err != nil {
+			return nil, err
+		}
+		return bp.GetQuotes(ctx, symbols)
+	}
+	return fanOut(ctx, rl.GetQuote, symbols)
+}
+
+func (rl *RateLimit) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(rl.provider)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	if err := rl.limiter.Wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	return hp.GetHistory(ctx, symbol, interval, from, to)
+}
+
+func (rl *RateLimit) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, rl.provider, symbols)
+}
+
+func (rl *RateLimit) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, rl.provider, symbols)
+}
+
+// Limiter returns the limiter used by the decorator
+func (rl *RateLimit) Limiter() *Limiter {
+	return rl.limiter
+}
+
+// Close closes the limiter if it was created by NewRateLimit
+func (rl *RateLimit) Close() error {
+	if rl.owned {
+		return rl.limiter.Close()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := decorators.NewLimiter(100, 2)
+	defer l.Close()
+
+	// 1. Burst is served immediately
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if time.Since(start) > 5*time.Millisecond {
+		t.Error("expected burst to be immediate")
+	}
+
+	// 2. Next token is refilled at the steady rate (10ms)
+	if err := l.Wait(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 8*time.Millisecond {
+		t.Errorf("expected to wait for refill, waited %v", elapsed)
+	}
+
+	// 3. Weighted cost larger than the burst is rejected
+	if err := l.Wait(ctx, 3); err == nil {
+		t.Error("expected error for cost above burst")
+	}
+}
+
+func TestLimiterFractionalRate(t *testing.T) {
+	l := decorators.NewLimiter(decorators.Per(30, time.Minute), 1)
+
+	if !l.Allow(1) {
+		t.Fatal("expected first token to be available")
+	}
+	if l.Allow(1) {
+		t.Error("expected second token to need 2s of refill")
+	}
+
+	// A waiter is released by Close
+	errCh := make(chan error, 1)
+	go func() { errCh <- l.Wait(context.Background(), 1) }()
+	time.Sleep(5 * time.Millisecond)
+	l.Close()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, decorators.ErrLimiterClosed) {
+			t.Errorf("expected ErrLimiterClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter not released by Close")
+	}
+}
+
+func TestRateLimitNoGoroutineLeak(t *testing.T) {
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		rl := decorators.NewRateLimit(mock, 10)
+		_, _ = rl.GetQuote(context.Background(), "BTC")
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected no goroutines to leak, had %d now %d", before, after)
+	}
+}
+
+func TestSharedRateLimit(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+		},
+	}
+
+	shared := decorators.NewLimiter(1, 2)
+	a := decorators.NewSharedRateLimit(mock, shared)
+	b := decorators.NewSharedRateLimit(mock, shared)
+
+	_, _ = a.GetQuote(ctx, "BTC")
+	_, _ = b.GetQuote(ctx, "ETH")
+
+	// Both decorators drew from the same bucket
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := a.GetQuote(timeout, "BTC"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected shared bucket to be empty, got %v", err)
+	}
+
+	// Closing a shared decorator leaves the limiter usable
+	_ = a.Close()
+	if !shared.Allow(0) {
+		t.Error("expected shared limiter to remain open")
+	}
+}
+
+func TestRateLimitGetQuotesFansOut(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			if symbol == "NOPE" {
+				return nil, domain.ErrSymbolNotFound
+			}
+			return &domain.Quote{Symbol: symbol}, nil
+		},
+	}
+	rl := decorators.NewRateLimit(mock, 100)
+	defer rl.Close()
+
+	// Without native batching the symbols are fetched concurrently, still one token each
+	quotes, err := rl.GetQuotes(context.Background(), []string{"A", "B", "C", "D", "NOPE"})
+	if peak.Load() < 2 {
+		t.Errorf("expected concurrent fetches, peak was %d", peak.Load())
+	}
+	if len(quotes) != 4 {
+		t.Errorf("expected 4 quotes, got %d", len(quotes))
+	}
+	var be *domain.BatchError
+	if !errors.As(err, &be) || !errors.Is(be.Errors["NOPE"], domain.ErrSymbolNotFound) {
+		t.Errorf("expected NOPE to fail as not found, got %v", err)
+	}
+}
@@ -1,9 +1,13 @@
 package decorators
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
+	"net/http"
 	"sync"
 	"time"
 
@@ -11,70 +15,533 @@ import (
 	"markets-sdk/pkg/ports"
 )
 
-// CircuitBreaker is a decorator that implements the Circuit Breaker pattern
+// CircuitBreaker is a decorator that implements the Circuit Breaker pattern.
+// It opens after failureThreshold consecutive failures and, when a sliding window
+// is configured, when the failure rate or slow-call rate of the window is too high.
 type CircuitBreaker struct {
 	provider         ports.Provider
 	failureThreshold int
 	resetTimeout     time.Duration
 
-	mu              sync.Mutex
-	failures        int
-	state           state
-	lastFailureTime time.Time
+	windowSize       int
+	windowDuration   time.Duration
+	minCalls         int
+	failureRate      float64
+	slowCallRate     float64
+	slowCallDuration time.Duration
+	halfOpenProbes   int
+	perSymbol        bool
+	maxSymbols       int
+	isFailure        func(error) bool
+	onStateChange    func(scope string, from, to State)
+
+	mu       sync.Mutex
+	breakers map[string]*list.Element
+	order    *list.List // front is most recently used
 }
 
-type state int
+// defaultMaxSymbols bounds the per-symbol breakers kept by WithPerSymbol
+const defaultMaxSymbols = 10000
+
+// State is the state of a circuit breaker
+type State int
 
 const (
-	stateClosed state = iota
-	stateOpen
-	stateHalfOpen
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
 )
 
-func NewCircuitBreaker(provider ports.Provider, failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// breaker holds the state for one scope (the whole provider or a single symbol)
+type breaker struct {
+	scope          string
+	state          State
+	consecutive    int
+	openedAt       time.Time
+	window         []callOutcome
+	active         int // calls in flight, probes included
+	probes         int // half-open calls in flight
+	probeSuccesses int
+}
+
+// idle reports whether b holds nothing a fresh breaker would not
+func (b *breaker) idle() bool {
+	return b.state == StateClosed && b.consecutive == 0 && len(b.window) == 0 && b.active == 0
+}
+
+// outcome classifies a finished call
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored calls, such as those cancelled by the caller, say nothing about
+	// the upstream's health
+	outcomeIgnored
+)
+
+type callOutcome struct {
+	at     time.Time
+	failed bool
+	slow   bool
+}
+
+// CircuitBreakerOption configures a CircuitBreaker
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// WithSlidingWindow evaluates rates over the last size calls and/or the calls of the
+// last duration. Zero disables the respective bound.
+func WithSlidingWindow(size int, duration time.Duration) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.windowSize = size
+		cb.windowDuration = duration
+	}
+}
+
+// WithMinimumCalls sets how many calls the window needs before rates are evaluated
+func WithMinimumCalls(n int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.minCalls = n
+	}
+}
+
+// WithFailureRateThreshold opens the breaker when the failure rate of the window
+// reaches rate (0-1)
+func WithFailureRateThreshold(rate float64) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.failureRate = rate
+	}
+}
+
+// WithSlowCallThreshold treats calls taking longer than d as slow and opens the
+// breaker when the slow-call rate of the window reaches rate (0-1)
+func WithSlowCallThreshold(d time.Duration, rate float64) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.slowCallDuration = d
+		cb.slowCallRate = rate
+	}
+}
+
+// WithHalfOpenProbes sets how many calls are let through while half-open. The breaker
+// closes once they all succeed. Defaults to 1.
+func WithHalfOpenProbes(n int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		if n > 0 {
+			cb.halfOpenProbes = n
+		}
+	}
+}
+
+// WithPerSymbol keeps a separate breaker for every symbol instead of one per provider.
+// Breakers holding no state are dropped, and at most WithMaxSymbols are kept.
+func WithPerSymbol() CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.perSymbol = true
+	}
+}
+
+// WithMaxSymbols bounds the per-symbol breakers, evicting the least recently used
+// once n is exceeded. Defaults to 10000.
+func WithMaxSymbols(n int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		if n > 0 {
+			cb.maxSymbols = n
+		}
+	}
+}
+
+// WithFailurePredicate decides which errors count as failures. Errors for which fn
+// returns false are ignored: they neither count as failures nor as successes. By
+// default every error counts except cancellation, unknown symbols, unsupported
+// operations and calls rejected by a saturated bulkhead.
+func WithFailurePredicate(fn func(error) bool) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.isFailure = fn
+	}
+}
+
+// WithOnStateChange calls fn on every transition. scope is the symbol for per-symbol
+// breakers and empty otherwise. fn is called synchronously, outside the breaker's lock.
+func WithOnStateChange(fn func(scope string, from, to State)) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.onStateChange = fn
+	}
+}
+
+// NewCircuitBreaker creates a breaker that opens after failureThreshold consecutive
+// failures (0 disables this trigger) and probes again after resetTimeout
+func NewCircuitBreaker(provider ports.Provider, failureThreshold int, resetTimeout time.Duration, opts ...CircuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
 		provider:         provider,
 		failureThreshold: failureThreshold,
 		resetTimeout:     resetTimeout,
-		state:            stateClosed,
+		halfOpenProbes:   1,
+		maxSymbols:       defaultMaxSymbols,
+		isFailure:        defaultIsFailure,
+		breakers:         make(map[string]*list.Element),
+		order:            list.New(),
+	}
+	for _, opt := range opts {
+		opt(cb)
 	}
+	return cb
 }
 
-func (cb *CircuitBreaker) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+func defaultIsFailure(err error) bool {
+	return !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, domain.ErrSymbolNotFound) &&
+		!errors.Is(err, domain.ErrNotSupported) &&
+		!errors.Is(err, domain.ErrBulkheadFull)
+}
+
+// State returns the current state of the provider-wide breaker
+func (cb *CircuitBreaker) State() State {
+	return cb.StateFor("")
+}
+
+// StateFor returns the state of the breaker guarding symbol. Without WithPerSymbol
+// all symbols share the provider-wide breaker.
+func (cb *CircuitBreaker) StateFor(symbol string) State {
 	cb.mu.Lock()
-	if cb.state == stateOpen {
-		if time.Since(cb.lastFailureTime) > cb.resetTimeout {
-			cb.state = stateHalfOpen
-		} else {
-			cb.mu.Unlock()
-			return nil, fmt.Errorf("circuit breaker is open")
+	defer cb.mu.Unlock()
+
+	el, ok := cb.breakers[cb.scope(symbol)]
+	if !ok {
+		return StateClosed
+	}
+	b := el.Value.(*breaker)
+	if b.state == StateOpen && time.Since(b.openedAt) > cb.resetTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (cb *CircuitBreaker) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return guarded(cb, cb.scope(symbol), func() (*domain.Quote, error) {
+		return cb.provider.GetQuote(ctx, symbol)
+	})
+}
+
+// GetQuotes sends a native batch through the provider-wide breaker, where a partial
+// result counts as a success, or with WithPerSymbol only sends the symbols whose
+// breaker lets them through and records an outcome for each
+func (cb *CircuitBreaker) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(cb.provider)
+	if !ok {
+		return fanOut(ctx, cb.GetQuote, symbols)
+	}
+
+	if !cb.perSymbol {
+		c, err := cb.admit("")
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		quotes, err := bp.GetQuotes(ctx, symbols)
+		cb.finish(c, cb.batchOutcome(quotes, err), time.Since(start))
+		return quotes, err
+	}
+
+	failed := make(map[string]error)
+	calls := make(map[string]call, len(symbols))
+	admitted := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		c, err := cb.admit(s)
+		if err != nil {
+			failed[s] = err
+			continue
+		}
+		calls[s] = c
+		admitted = append(admitted, s)
+	}
+
+	var quotes map[string]*domain.Quote
+	if len(admitted) > 0 {
+		start := time.Now()
+		var err error
+		quotes, err = bp.GetQuotes(ctx, admitted)
+		elapsed := time.Since(start)
+
+		var batchErr *domain.BatchError
+		errors.As(err, &batchErr)
+		for _, s := range admitted {
+			symbolErr := err
+			if _, ok := quotes[s]; ok {
+				symbolErr = nil
+			} else if batchErr != nil {
+				symbolErr = batchErr.Errors[s]
+			}
+			if symbolErr != nil {
+				failed[s] = symbolErr
+			}
+			cb.finish(calls[s], symbolErr, elapsed)
+		}
+	}
+	if quotes == nil {
+		quotes = make(map[string]*domain.Quote)
+	}
+
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+func (cb *CircuitBreaker) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(cb.provider)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	return guarded(cb, cb.scope(symbol), func() ([]domain.Candle, error) {
+		return hp.GetHistory(ctx, symbol, interval, from, to)
+	})
+}
+
+// Subscribe bypasses the breaker: a stream has no single outcome to record
+func (cb *CircuitBreaker) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, cb.provider, symbols)
+}
+
+func (cb *CircuitBreaker) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, cb.provider, symbols)
+}
+
+// call is a call admitted by the breaker of scope
+type call struct {
+	scope string
+	b     *breaker
+	probe bool
+}
+
+// guarded runs fn if the breaker of scope admits it and records its outcome
+// batchOutcome reduces a batch guarded by the provider-wide breaker to one outcome.
+// A BatchError matches the sentinels of all its symbols, so they are classified one by
+// one: any failure fails the call. Otherwise the call succeeded if the upstream
+// answered with quotes, and is ignored if every symbol failed with an ignored error.
+func (cb *CircuitBreaker) batchOutcome(quotes map[string]*domain.Quote, err error) error {
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) == 0 {
+		if len(quotes) > 0 {
+			return nil
 		}
+		return err
 	}
+	var ignored error
+	for _, symbolErr := range batchErr.Errors {
+		if cb.isFailure(symbolErr) {
+			return symbolErr
+		}
+		ignored = symbolErr
+	}
+	if len(quotes) > 0 {
+		return nil
+	}
+	return ignored
+}
+
+func guarded[T any](cb *CircuitBreaker, scope string, fn func() (T, error)) (T, error) {
+	c, err := cb.admit(scope)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	start := time.Now()
+	v, err := fn()
+	cb.finish(c, err, time.Since(start))
+	return v, err
+}
+
+// admit lets a call through the breaker of scope, or fails with domain.ErrCircuitOpen
+func (cb *CircuitBreaker) admit(scope string) (call, error) {
+	cb.mu.Lock()
+	b := cb.breaker(scope)
+	from := b.state
+	if b.state == StateOpen && time.Since(b.openedAt) > cb.resetTimeout {
+		b.state = StateHalfOpen
+		b.probes, b.probeSuccesses = 0, 0
+	}
+	if b.state == StateOpen || (b.state == StateHalfOpen && b.probes >= cb.halfOpenProbes) {
+		to := b.state
+		cb.mu.Unlock()
+		cb.notify(scope, from, to)
+		return call{}, domain.ErrCircuitOpen
+	}
+	probe := b.state == StateHalfOpen
+	if probe {
+		b.probes++
+	}
+	b.active++
+	to := b.state
 	cb.mu.Unlock()
+	cb.notify(scope, from, to)
 
-	quote, err := cb.provider.GetQuote(ctx, symbol)
+	return call{scope: scope, b: b, probe: probe}, nil
+}
 
+// finish records the outcome of an admitted call
+func (cb *CircuitBreaker) finish(c call, err error, elapsed time.Duration) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	from := c.b.state
+	cb.record(c.b, c.probe, cb.classify(err), elapsed)
+	to := c.b.state
+	if cb.perSymbol && c.b.idle() {
+		cb.remove(c.b)
+	}
+	cb.mu.Unlock()
+	cb.notify(c.scope, from, to)
+}
 
-	if err != nil {
-		cb.failures++
-		cb.lastFailureTime = time.Now()
-		if cb.failures >= cb.failureThreshold {
-			cb.state = stateOpen
+func (cb *CircuitBreaker) classify(err error) outcome {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case cb.isFailure(err):
+		return outcomeFailure
+	default:
+		return outcomeIgnored
+	}
+}
+
+// record updates the breaker with the outcome of a call. An ignored call only gives
+// back its probe slot. Must hold cb.mu.
+func (cb *CircuitBreaker) record(b *breaker, probe bool, o outcome, elapsed time.Duration) {
+	now := time.Now()
+	failed := o == outcomeFailure
+
+	b.active--
+	if probe {
+		b.probes--
+		if b.state != StateHalfOpen || o == outcomeIgnored {
+			return // another probe already decided, or this one proved nothing
+		}
+		if failed {
+			cb.trip(b, now)
+			return
 		}
-		return nil, err
+		b.probeSuccesses++
+		if b.probeSuccesses >= cb.halfOpenProbes {
+			b.state = StateClosed
+			b.consecutive = 0
+			b.window = b.window[:0]
+		}
+		return
 	}
 
-	// Success
-	if cb.state == stateHalfOpen {
-		cb.state = stateClosed
-		cb.failures = 0
-	} else if cb.state == stateClosed {
-		cb.failures = 0
+	if b.state != StateClosed || o == outcomeIgnored {
+		return
 	}
 
-	return quote, nil
+	if failed {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+	if cb.failureThreshold > 0 && b.consecutive >= cb.failureThreshold {
+		cb.trip(b, now)
+		return
+	}
+
+	if cb.windowSize <= 0 && cb.windowDuration <= 0 {
+		return
+	}
+	b.window = append(b.window, callOutcome{
+		at:     now,
+		failed: failed,
+		slow:   cb.slowCallDuration > 0 && elapsed > cb.slowCallDuration,
+	})
+	if cb.windowSize > 0 && len(b.window) > cb.windowSize {
+		b.window = b.window[len(b.window)-cb.windowSize:]
+	}
+	if cb.windowDuration > 0 {
+		i := 0
+		for i < len(b.window) && now.Sub(b.window[i].at) > cb.windowDuration {
+			i++
+		}
+		b.window = b.window[i:]
+	}
+
+	total := len(b.window)
+	if total == 0 || total < cb.minCalls {
+		return
+	}
+	var failures, slow int
+	for _, o := range b.window {
+		if o.failed {
+			failures++
+		}
+		if o.slow {
+			slow++
+		}
+	}
+	if (cb.failureRate > 0 && float64(failures)/float64(total) >= cb.failureRate) ||
+		(cb.slowCallRate > 0 && float64(slow)/float64(total) >= cb.slowCallRate) {
+		cb.trip(b, now)
+	}
+}
+
+func (cb *CircuitBreaker) trip(b *breaker, now time.Time) {
+	b.state = StateOpen
+	b.openedAt = now
+	b.consecutive = 0
+	b.window = b.window[:0]
+}
+
+func (cb *CircuitBreaker) scope(symbol string) string {
+	if cb.perSymbol {
+		return symbol
+	}
+	return ""
+}
+
+// breaker returns the breaker for scope, creating it if needed and evicting the
+// least recently used breakers beyond maxSymbols. Calls in flight on an
+// evicted breaker finish against it unnoticed. Must hold cb.mu.
+func (cb *CircuitBreaker) breaker(scope string) *breaker {
+	if el, ok := cb.breakers[scope]; ok {
+		cb.order.MoveToFront(el)
+		return el.Value.(*breaker)
+	}
+
+	b := &breaker{scope: scope, state: StateClosed}
+	cb.breakers[scope] = cb.order.PushFront(b)
+	for cb.order.Len() > cb.maxSymbols {
+		cb.remove(cb.order.Back().Value.(*breaker))
+	}
+	return b
+}
+
+// remove forgets b unless it has already been replaced. Must hold cb.mu.
+func (cb *CircuitBreaker) remove(b *breaker) {
+	if el, ok := cb.breakers[b.scope]; ok && el.Value == b {
+		cb.order.Remove(el)
+		delete(cb.breakers, b.scope)
+	}
+}
+
+// Len returns the number of breakers holding state
+func (cb *CircuitBreaker) Len() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.order.Len()
+}
+
+func (cb *CircuitBreaker) notify(scope string, from, to State) {
+	if from != to && cb.onStateChange != nil {
+		cb.onStateChange(scope, from, to)
+	}
 }
 
 // Retry is a decorator that implements retries with exponential backoff
@@ -82,76 +549,326 @@ type Retry struct {
 	provider   ports.Provider
 	maxRetries int
 	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxHint    time.Duration
+	jitter     Jitter
+	retryable  func(error) bool
+	budget     *RetryBudget
+}
+
+// Jitter selects how retry delays are randomized
+type Jitter int
+
+const (
+	// JitterNone uses the plain exponential delay
+	JitterNone Jitter = iota
+	// JitterFull picks a random delay between zero and the exponential delay
+	JitterFull
+	// JitterDecorrelated picks a random delay between baseDelay and three times the previous delay
+	JitterDecorrelated
+)
+
+// RetryOption configures a Retry
+type RetryOption func(*Retry)
+
+// WithRetryable replaces the predicate deciding which errors are retried.
+// Defaults to DefaultRetryable.
+func WithRetryable(fn func(error) bool) RetryOption {
+	return func(r *Retry) {
+		r.retryable = fn
+	}
+}
+
+// WithJitter randomizes the delay between attempts
+func WithJitter(j Jitter) RetryOption {
+	return func(r *Retry) {
+		r.jitter = j
+	}
+}
+
+const (
+	// maxBackoff caps the delay between attempts when WithMaxDelay is not set
+	maxBackoff = time.Hour
+	// defaultMaxRetryAfter is the longest Retry-After hint waited for by default
+	defaultMaxRetryAfter = time.Minute
+)
+
+// WithMaxDelay caps the delay between attempts. Defaults to one hour. A Retry-After
+// hint from the upstream takes precedence over the cap, see WithMaxRetryAfter.
+func WithMaxDelay(d time.Duration) RetryOption {
+	return func(r *Retry) {
+		r.maxDelay = d
+	}
+}
+
+// WithMaxRetryAfter sets the longest Retry-After hint the decorator waits for. A longer
+// hint ends the retries with the rate limit error. Defaults to one minute.
+func WithMaxRetryAfter(d time.Duration) RetryOption {
+	return func(r *Retry) {
+		if d > 0 {
+			r.maxHint = d
+		}
+	}
 }
 
-func NewRetry(provider ports.Provider, maxRetries int, baseDelay time.Duration) *Retry {
-	return &Retry{
+// WithRetryBudget limits retries using a budget that can be shared by several decorators
+func WithRetryBudget(b *RetryBudget) RetryOption {
+	return func(r *Retry) {
+		r.budget = b
+	}
+}
+
+func NewRetry(provider ports.Provider, maxRetries int, baseDelay time.Duration, opts ...RetryOption) *Retry {
+	r := &Retry{
 		provider:   provider,
 		maxRetries: maxRetries,
 		baseDelay:  baseDelay,
+		maxHint:    defaultMaxRetryAfter,
+		retryable:  DefaultRetryable,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRetryable retries every error except cancellation, unknown symbols,
+// unsupported operations, an open circuit breaker, rejected credentials and 4xx
+// responses other than 429. Timeouts of the provider's own HTTP client are reported
+// as domain.ErrUpstreamUnavailable and retried; whether the caller gave up is decided
+// by the caller's context, not by the error.
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, domain.ErrUpstreamUnavailable) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, domain.ErrSymbolNotFound) || errors.Is(err, domain.ErrNotSupported) || errors.Is(err, domain.ErrCircuitOpen) ||
+		errors.Is(err, domain.ErrUnauthorized) {
+		return false
 	}
+	var httpErr *domain.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusRequestTimeout
+	}
+	return true
 }
 
 func (r *Retry) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
-	var err error
-	var quote *domain.Quote
+	return retried(ctx, r, func(ctx context.Context) (*domain.Quote, error) {
+		return r.provider.GetQuote(ctx, symbol)
+	})
+}
+
+// GetQuotes retries a native batch, asking again only for the symbols that failed
+// with a retryable error
+func (r *Retry) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(r.provider)
+	if !ok {
+		return fanOut(ctx, r.GetQuote, symbols)
+	}
+
+	quotes := make(map[string]*domain.Quote, len(symbols))
+	failed := make(map[string]error)
+	pending := symbols
+	_, err := retried(ctx, r, func(ctx context.Context) (struct{}, error) {
+		got, err := bp.GetQuotes(ctx, pending)
+		for _, s := range pending {
+			if q, ok := got[s]; ok {
+				quotes[s] = q
+				delete(failed, s)
+			}
+		}
+
+		var batchErr *domain.BatchError
+		if !errors.As(err, &batchErr) {
+			return struct{}{}, err
+		}
+		var retry []string
+		again := make(map[string]error)
+		for s, e := range batchErr.Errors {
+			failed[s] = e
+			if r.retryable(e) {
+				retry = append(retry, s)
+				again[s] = e
+			}
+		}
+		if len(retry) == 0 {
+			return struct{}{}, nil
+		}
+		pending = retry
+		return struct{}{}, &domain.BatchError{Errors: again}
+	})
+
+	var batchErr *domain.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		// The whole request failed: every pending symbol shares the error
+		if len(quotes) == 0 && len(failed) == 0 {
+			return nil, err
+		}
+		for _, s := range pending {
+			failed[s] = err
+		}
+	}
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+func (r *Retry) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	hp, ok := ports.AsHistoricalProvider(r.provider)
+	if !ok {
+		return nil, notSupported("history")
+	}
+	return retried(ctx, r, func(ctx context.Context) ([]domain.Candle, error) {
+		return hp.GetHistory(ctx, symbol, interval, from, to)
+	})
+}
+
+// Subscribe is not retried: the caller re-subscribes when the stream drops
+func (r *Retry) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, r.provider, symbols)
+}
+
+func (r *Retry) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, r.provider, symbols)
+}
+
+// retried calls fn until it succeeds, fails with an error that is not retryable or
+// runs out of attempts
+func retried[T any](ctx context.Context, r *Retry, fn func(context.Context) (T, error)) (T, error) {
+	var (
+		zero T
+		err  error
+		prev time.Duration
+	)
 
 	for i := 0; i <= r.maxRetries; i++ {
 		if i > 0 {
-			// Exponential backoff: baseDelay * 2^(i-1)
-			delay := r.baseDelay * time.Duration(math.Pow(2, float64(i-1)))
+			if r.budget != nil && !r.budget.allow() {
+				return zero, fmt.Errorf("retry budget exhausted after %d retries: %w", i-1, err)
+			}
+
+			delay, ok := r.delay(i, prev, err)
+			prev = delay
+			if deadline, set := ctx.Deadline(); !ok || set && time.Until(deadline) < delay {
+				// Waiting would outlive the caller or the upstream asks for too long
+				// a pause; fail fast with the real cause
+				return zero, fmt.Errorf("after %d retries: %w", i-1, err)
+			}
+
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				return zero, ctx.Err()
 			case <-time.After(delay):
 			}
 		}
 
-		quote, err = r.provider.GetQuote(ctx, symbol)
+		var v T
+		v, err = fn(withAttemptsLeft(ctx, r.maxRetries-i+1))
 		if err == nil {
-			return quote, nil
+			if r.budget != nil {
+				r.budget.onSuccess()
+			}
+			return v, nil
+		}
+		if ctx.Err() != nil || !r.retryable(err) {
+			return zero, err
+		}
+		if r.budget != nil {
+			r.budget.onFailure()
 		}
 	}
-	return nil, fmt.Errorf("after %d retries: %w", r.maxRetries, err)
+	return zero, fmt.Errorf("after %d retries: %w", r.maxRetries, err)
 }
 
-// RateLimit is a decorator that implements simple token bucket rate limiting
-type RateLimit struct {
-	provider ports.Provider
-	tokens   chan struct{}
-}
+// delay computes the wait before the given attempt
+func (r *Retry) delay(attempt int, prev time.Duration, lastErr error) (time.Duration, bool) {
+	ceiling := r.maxDelay
+	if ceiling <= 0 {
+		ceiling = maxBackoff
+	}
+
+	base := min(max(r.baseDelay, 0), ceiling)
 
-func NewRateLimit(provider ports.Provider, rps int) *RateLimit {
-	rl := &RateLimit{
-		provider: provider,
-		tokens:   make(chan struct{}, rps),
+	// Exponential backoff: baseDelay * 2^(attempt-1), saturating at the ceiling
+	// before it can overflow
+	d := base
+	for i := 1; i < attempt; i++ {
+		if d > ceiling/2 {
+			d = ceiling
+			break
+		}
+		d *= 2
 	}
-	// Fill bucket initially
-	for i := 0; i < rps; i++ {
-		rl.tokens <- struct{}{}
+
+	switch r.jitter {
+	case JitterFull:
+		d = randDuration(0, d)
+	case JitterDecorrelated:
+		prev = min(max(prev, base), ceiling)
+		hi := ceiling
+		if prev < ceiling/3 {
+			hi = prev * 3
+		}
+		d = randDuration(base, hi)
 	}
 
-	// Leak tokens (refill)
-	go func() {
-		ticker := time.NewTicker(time.Second / time.Duration(rps))
-		defer ticker.Stop()
-		for range ticker.C {
-			select {
-			case rl.tokens <- struct{}{}:
-			default:
-				// Bucket full
-			}
+	var rlErr *domain.RateLimitError
+	if errors.As(lastErr, &rlErr) && rlErr.RetryAfter > d {
+		if rlErr.RetryAfter > r.maxHint {
+			return d, false
 		}
-	}()
+		d = rlErr.RetryAfter
+	}
+	return d, true
+}
+
+// randDuration returns a random duration in [lo, hi]
+func randDuration(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	if hi-lo == math.MaxInt64 {
+		return lo + rand.N(hi-lo)
+	}
+	return lo + rand.N(hi-lo+1)
+}
 
-	return rl
+// RetryBudget throttles retries across all calls sharing it, in the style of gRPC
+// retry throttling: every retryable failure costs one token, every success earns
+// ratio tokens, and retries are only allowed while more than half the tokens remain.
+type RetryBudget struct {
+	mu        sync.Mutex
+	maxTokens float64
+	tokens    float64
+	ratio     float64
 }
 
-func (rl *RateLimit) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-rl.tokens:
-		return rl.provider.GetQuote(ctx, symbol)
+func NewRetryBudget(maxTokens, ratio float64) *RetryBudget {
+	return &RetryBudget{
+		maxTokens: maxTokens,
+		tokens:    maxTokens,
+		ratio:     ratio,
 	}
 }
+
+func (b *RetryBudget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+func (b *RetryBudget) onFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Max(0, b.tokens-1)
+}
+
+func (b *RetryBudget) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.maxTokens, b.tokens+b.ratio)
+}
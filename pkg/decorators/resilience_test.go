@@ -3,11 +3,17 @@ package decorators_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"markets-sdk/pkg/decorators"
 	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/providers/coingecko"
 )
 
 func TestCircuitBreaker(t *testing.T) {
@@ -38,6 +44,9 @@ func TestCircuitBreaker(t *testing.T) {
 	if err == nil || err.Error() != "circuit breaker is open" {
 		t.Errorf("expected circuit breaker open error, got %v", err)
 	}
+	if !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
 
 	// 4. Wait for reset
 	time.Sleep(150 * time.Millisecond)
@@ -45,20 +54,20 @@ func TestCircuitBreaker(t *testing.T) {
 	// 5. Should allow one request (Half-Open)
 	// Let's make it succeed this time
 	mock.QuoteFn = func(ctx context.Context, symbol string) (*domain.Quote, error) {
-		return &domain.Quote{Price: 100}, nil
+		return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
 	}
 
 	q, err := cb.GetQuote(ctx, "BTC")
 	if err != nil {
 		t.Errorf("expected success in half-open state, got %v", err)
 	}
-	if q.Price != 100 {
+	if !q.Price.Equal(domain.NewDecimalFromInt(100)) {
 		t.Error("expected price 100")
 	}
 
 	// 6. Should be closed now (allow more requests)
 	mock.QuoteFn = func(ctx context.Context, symbol string) (*domain.Quote, error) {
-		return &domain.Quote{Price: 200}, nil
+		return &domain.Quote{Price: domain.NewDecimalFromInt(200)}, nil
 	}
 	q, err = cb.GetQuote(ctx, "BTC")
 	if err != nil {
@@ -77,7 +86,7 @@ func TestRetry(t *testing.T) {
 			if calls <= 2 {
 				return nil, errors.New("fail")
 			}
-			return &domain.Quote{Price: 100}, nil
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
 		},
 	}
 
@@ -88,10 +97,401 @@ func TestRetry(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
-	if q.Price != 100 {
+	if !q.Price.Equal(domain.NewDecimalFromInt(100)) {
 		t.Errorf("expected 100, got %v", q.Price)
 	}
 	if calls != 3 {
 		t.Errorf("expected 3 calls, got %d", calls)
 	}
 }
+
+func TestRetryClassification(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			return nil, &domain.HTTPError{StatusCode: 404}
+		},
+	}
+	r := decorators.NewRetry(mock, 3, time.Millisecond)
+
+	_, err := r.GetQuote(ctx, "NOPE")
+	if !errors.Is(err, domain.ErrSymbolNotFound) {
+		t.Errorf("expected ErrSymbolNotFound, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected not-found to be returned without retrying, got %d calls", calls)
+	}
+
+	// Custom predicate
+	calls = 0
+	r = decorators.NewRetry(mock, 3, time.Millisecond, decorators.WithRetryable(func(error) bool { return true }))
+	_, _ = r.GetQuote(ctx, "NOPE")
+	if calls != 4 {
+		t.Errorf("expected 4 calls with custom predicate, got %d", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			if calls == 1 {
+				return nil, &domain.RateLimitError{RetryAfter: 50 * time.Millisecond}
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	r := decorators.NewRetry(mock, 1, time.Millisecond, decorators.WithMaxDelay(5*time.Millisecond), decorators.WithJitter(decorators.JitterFull))
+
+	start := time.Now()
+	if _, err := r.GetQuote(ctx, "BTC"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected Retry-After to be honored, retried after %v", elapsed)
+	}
+
+	// A hint longer than the caller's deadline fails fast
+	calls = 0
+	deadlineCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := r.GetQuote(deadlineCtx, "BTC")
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			return nil, errors.New("fail")
+		},
+	}
+	budget := decorators.NewRetryBudget(4, 0.1)
+	r := decorators.NewRetry(mock, 10, time.Microsecond, decorators.WithRetryBudget(budget), decorators.WithJitter(decorators.JitterDecorrelated))
+
+	// 4 tokens: retries stop once only half remain
+	_, err := r.GetQuote(ctx, "BTC")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls before the budget is exhausted, got %d", calls)
+	}
+
+	// The budget is shared, so the next call gets no retries at all
+	calls = 0
+	_, _ = r.GetQuote(ctx, "BTC")
+	if calls != 1 {
+		t.Errorf("expected 1 call with an exhausted budget, got %d", calls)
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	ctx := context.Background()
+
+	fail := false
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if fail {
+				return nil, errors.New("failure")
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+
+	var transitions []string
+	cb := decorators.NewCircuitBreaker(mock, 0, 20*time.Millisecond,
+		decorators.WithSlidingWindow(4, 0),
+		decorators.WithMinimumCalls(4),
+		decorators.WithFailureRateThreshold(0.5),
+		decorators.WithOnStateChange(func(scope string, from, to decorators.State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		}),
+	)
+
+	// 1. Alternating outcomes reach a 50% failure rate on the 4th call
+	for i := 0; i < 4; i++ {
+		fail = i%2 == 1
+		_, _ = cb.GetQuote(ctx, "BTC")
+	}
+	if cb.State() != decorators.StateOpen {
+		t.Fatalf("expected open breaker, got %s", cb.State())
+	}
+
+	// 2. After the reset timeout a probe closes it again
+	time.Sleep(30 * time.Millisecond)
+	if cb.State() != decorators.StateHalfOpen {
+		t.Errorf("expected half-open breaker, got %s", cb.State())
+	}
+	fail = false
+	if _, err := cb.GetQuote(ctx, "BTC"); err != nil {
+		t.Errorf("expected probe to succeed, got %v", err)
+	}
+	if cb.State() != decorators.StateClosed {
+		t.Errorf("expected closed breaker, got %s", cb.State())
+	}
+
+	expected := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("expected transitions %v, got %v", expected, transitions)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	failing := true
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if failing {
+				return nil, errors.New("failure")
+			}
+			<-release
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	cb := decorators.NewCircuitBreaker(mock, 1, 10*time.Millisecond)
+
+	_, _ = cb.GetQuote(ctx, "BTC")
+	time.Sleep(20 * time.Millisecond)
+	failing = false
+
+	// Only one probe is let through while half-open
+	done := make(chan error)
+	go func() {
+		_, err := cb.GetQuote(ctx, "BTC")
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cb.GetQuote(ctx, "BTC"); !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Errorf("expected concurrent call to be rejected while probing, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("expected probe to succeed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerPerSymbol(t *testing.T) {
+	ctx := context.Background()
+
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if symbol == "BAD" {
+				return nil, errors.New("failure")
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	cb := decorators.NewCircuitBreaker(mock, 1, time.Minute, decorators.WithPerSymbol())
+
+	_, _ = cb.GetQuote(ctx, "BAD")
+	if _, err := cb.GetQuote(ctx, "BAD"); !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Errorf("expected open breaker for BAD, got %v", err)
+	}
+	if _, err := cb.GetQuote(ctx, "GOOD"); err != nil {
+		t.Errorf("expected GOOD to be unaffected, got %v", err)
+	}
+	if cb.StateFor("GOOD") != decorators.StateClosed || cb.StateFor("BAD") != decorators.StateOpen {
+		t.Errorf("unexpected states: GOOD=%s BAD=%s", cb.StateFor("GOOD"), cb.StateFor("BAD"))
+	}
+
+	// Unknown symbols do not trip the breaker
+	notFound := decorators.NewCircuitBreaker(&MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			return nil, domain.ErrSymbolNotFound
+		},
+	}, 1, time.Minute)
+	_, _ = notFound.GetQuote(ctx, "NOPE")
+	if notFound.State() != decorators.StateClosed {
+		t.Errorf("expected not-found to be ignored, got %s", notFound.State())
+	}
+}
+
+func TestCircuitBreakerPerSymbolBounded(t *testing.T) {
+	ctx := context.Background()
+
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			switch {
+			case symbol == "BAD":
+				return nil, errors.New("failure")
+			case strings.HasPrefix(symbol, "junk"):
+				return nil, domain.ErrSymbolNotFound
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+
+	// 1. Breakers without state are dropped
+	cb := decorators.NewCircuitBreaker(mock, 1, time.Minute, decorators.WithPerSymbol())
+	_, _ = cb.GetQuote(ctx, "BAD")
+	for i := 0; i < 1000; i++ {
+		_, _ = cb.GetQuote(ctx, fmt.Sprintf("junk-%d", i))
+		_, _ = cb.GetQuote(ctx, fmt.Sprintf("ok-%d", i))
+	}
+	if n := cb.Len(); n != 1 {
+		t.Errorf("expected only the BAD breaker to be kept, got %d", n)
+	}
+	if cb.StateFor("BAD") != decorators.StateOpen {
+		t.Errorf("expected BAD to stay open, got %s", cb.StateFor("BAD"))
+	}
+
+	// 2. Breakers with a window are capped
+	cb = decorators.NewCircuitBreaker(mock, 0, time.Minute, decorators.WithPerSymbol(),
+		decorators.WithSlidingWindow(10, 0), decorators.WithMaxSymbols(100))
+	for i := 0; i < 1000; i++ {
+		_, _ = cb.GetQuote(ctx, fmt.Sprintf("ok-%d", i))
+	}
+	if n := cb.Len(); n != 100 {
+		t.Errorf("expected 100 breakers, got %d", n)
+	}
+}
+
+func TestCircuitBreakerIgnoredOutcomes(t *testing.T) {
+	ctx := context.Background()
+
+	var err error
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			if err != nil {
+				return nil, err
+			}
+			return &domain.Quote{Price: domain.NewDecimalFromInt(100)}, nil
+		},
+	}
+	cb := decorators.NewCircuitBreaker(mock, 2, 10*time.Millisecond)
+
+	// 1. Ignored calls between failures do not reset the consecutive count
+	err = errors.New("failure")
+	_, _ = cb.GetQuote(ctx, "BTC")
+	err = context.Canceled
+	_, _ = cb.GetQuote(ctx, "BTC")
+	err = errors.New("failure")
+	_, _ = cb.GetQuote(ctx, "BTC")
+	if cb.State() != decorators.StateOpen {
+		t.Fatalf("expected open breaker, got %s", cb.State())
+	}
+
+	// 2. A cancelled probe does not close the breaker but frees the probe slot
+	time.Sleep(20 * time.Millisecond)
+	err = context.Canceled
+	if _, got := cb.GetQuote(ctx, "BTC"); !errors.Is(got, context.Canceled) {
+		t.Fatalf("expected the probe to run, got %v", got)
+	}
+	if cb.State() != decorators.StateHalfOpen {
+		t.Errorf("expected half-open breaker after a cancelled probe, got %s", cb.State())
+	}
+	err = nil
+	if _, got := cb.GetQuote(ctx, "BTC"); got != nil {
+		t.Errorf("expected a new probe to succeed, got %v", got)
+	}
+	if cb.State() != decorators.StateClosed {
+		t.Errorf("expected closed breaker, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerBatchOutcome(t *testing.T) {
+	ctx := context.Background()
+
+	var failed map[string]error
+	mock := &MockFullProvider{
+		QuotesFn: func(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+			return nil, &domain.BatchError{Errors: failed}
+		},
+	}
+	cb := decorators.NewCircuitBreaker(mock, 1, time.Minute)
+
+	// 1. Unknown symbols alone are ignored
+	failed = map[string]error{"NOPE": domain.ErrSymbolNotFound}
+	_, _ = cb.GetQuotes(ctx, []string{"NOPE"})
+	if cb.State() != decorators.StateClosed {
+		t.Fatalf("expected closed breaker, got %s", cb.State())
+	}
+
+	// 2. An unknown symbol does not hide an upstream failure in the same batch
+	failed = map[string]error{"NOPE": domain.ErrSymbolNotFound, "BTC": domain.ErrUpstreamUnavailable}
+	_, _ = cb.GetQuotes(ctx, []string{"NOPE", "BTC"})
+	if cb.State() != decorators.StateOpen {
+		t.Errorf("expected open breaker, got %s", cb.State())
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	calls := 0
+	mock := &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			calls++
+			return nil, &domain.RateLimitError{RetryAfter: time.Hour}
+		},
+	}
+	r := decorators.NewRetry(mock, 3, time.Millisecond)
+
+	// Without a caller deadline, an hour-long hint still ends the retries at once
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.GetQuote(context.Background(), "BTC")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, domain.ErrRateLimited) || calls != 1 {
+			t.Errorf("expected ErrRateLimited after one call, got %v after %d", err, calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry waited for the hint")
+	}
+}
+
+func TestRetryUpstreamTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond) // longer than the provider's HTTP timeout
+		}
+		fmt.Fprint(w, `{"bitcoin":{"usd":50000}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := coingecko.NewProvider(coingecko.WithBaseURL(srv.URL), coingecko.WithTimeout(50*time.Millisecond))
+	r := decorators.NewRetry(p, 2, time.Millisecond)
+
+	// The provider's own timeout is retried while the caller still waits
+	q, err := r.GetQuote(context.Background(), "bitcoin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.NewDecimalFromInt(50000)) || calls.Load() != 2 {
+		t.Errorf("expected a quote after 2 attempts, got %v after %d", q.Price, calls.Load())
+	}
+
+	// The caller's own deadline is not retried
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.GetQuote(ctx, "bitcoin"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 attempt after the caller gave up, got %d", n)
+	}
+}
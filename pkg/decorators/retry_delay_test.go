@@ -0,0 +1,47 @@
+package decorators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+func TestRetryDelaySaturates(t *testing.T) {
+	for _, r := range []*Retry{
+		NewRetry(nil, 100, time.Millisecond),
+		NewRetry(nil, 100, time.Millisecond, WithMaxDelay(math.MaxInt64)),
+		NewRetry(nil, 100, time.Millisecond, WithMaxDelay(math.MaxInt64), WithJitter(JitterFull)),
+		NewRetry(nil, 100, time.Millisecond, WithMaxDelay(math.MaxInt64), WithJitter(JitterDecorrelated)),
+		NewRetry(nil, 100, time.Millisecond, WithJitter(JitterDecorrelated)),
+	} {
+		var prev time.Duration
+		for attempt := 1; attempt <= 100; attempt++ {
+			d, ok := r.delay(attempt, prev, nil)
+			if !ok || d < 0 {
+				t.Fatalf("attempt %d: expected a non-negative delay, got %v", attempt, d)
+			}
+			if r.maxDelay == 0 && d > maxBackoff {
+				t.Fatalf("attempt %d: expected at most %v without a max delay, got %v", attempt, maxBackoff, d)
+			}
+			prev = d
+		}
+	}
+}
+
+func TestRetryDelayCapsRetryAfter(t *testing.T) {
+	r := NewRetry(nil, 3, time.Millisecond, WithMaxDelay(10*time.Millisecond))
+
+	if d, ok := r.delay(1, 0, &domain.RateLimitError{RetryAfter: 30 * time.Second}); !ok || d != 30*time.Second {
+		t.Errorf("expected a short hint to override the max delay, got %v, %v", d, ok)
+	}
+	if _, ok := r.delay(1, 0, &domain.RateLimitError{RetryAfter: time.Hour}); ok {
+		t.Error("expected a hint beyond the default cap to stop retrying")
+	}
+
+	r = NewRetry(nil, 3, time.Millisecond, WithMaxRetryAfter(2*time.Hour))
+	if d, ok := r.delay(1, 0, &domain.RateLimitError{RetryAfter: time.Hour}); !ok || d != time.Hour {
+		t.Errorf("expected the hint within WithMaxRetryAfter, got %v, %v", d, ok)
+	}
+}
@@ -0,0 +1,104 @@
+package decorators
+
+import (
+	"context"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+type attemptsLeftKey struct{}
+
+// withAttemptsLeft records how many attempts, including the current one, the caller
+// may still make within its deadline. Set by Retry, read by Timeout.
+func withAttemptsLeft(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptsLeftKey{}, n)
+}
+
+func attemptsLeft(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptsLeftKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// Timeout is a decorator that bounds each attempt separately from the caller's overall
+// deadline. When wrapped by Retry, every attempt gets an equal share of the remaining
+// budget, so a slow first attempt cannot consume the time reserved for retries.
+type Timeout struct {
+	provider ports.Provider
+	timeout  time.Duration
+}
+
+// NewTimeout bounds every attempt to timeout. A zero timeout relies solely on the
+// share of the caller's deadline.
+func NewTimeout(provider ports.Provider, timeout time.Duration) *Timeout {
+	return &Timeout{
+		provider: provider,
+		timeout:  timeout,
+	}
+}
+
+// GetQuote returns *domain.TimeoutError when the attempt ran out of time and the
+// caller's own context error when the caller's deadline passed
+func (t *Timeout) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
+	return bounded(ctx, t, func(ctx context.Context) (*domain.Quote, error) {
+		return t.provider.GetQuote(ctx, symbol)
+	})
+}
+
+// GetQuotes bounds a native batch like a single attempt
+func (t *Timeout) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	bp, ok := ports.AsBatchProvider(t.provider)
+	if !ok {
+		return fanOut(ctx, t.GetQuote, symbols)
+	}
+	return bounded(ctx, t, func(ctx context.Context) (map[string]*domain.Quote, error) {
+		return bp.GetQuotes(ctx, symbols)
+	})
+}
+
+func (t *Timeout) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	return bounded(ctx, t, func(ctx context.Context) ([]domain.Candle, error) {
+		return getHistory(ctx, t.provider, symbol, interval, from, to)
+	})
+}
+
+// Subscribe is not bounded: a subscription lasts as long as ctx
+func (t *Timeout) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	return subscribe(ctx, t.provider, symbols)
+}
+
+func (t *Timeout) Unsubscribe(ctx context.Context, symbols []string) error {
+	return unsubscribe(ctx, t.provider, symbols)
+}
+
+// bounded runs fn as one attempt
+func bounded[T any](ctx context.Context, t *Timeout, fn func(context.Context) (T, error)) (T, error) {
+	d := t.attemptTimeout(ctx)
+	if d <= 0 {
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	v, err := fn(attemptCtx)
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded {
+		var zero T
+		return zero, &domain.TimeoutError{Limit: d}
+	}
+	return v, err
+}
+
+func (t *Timeout) attemptTimeout(ctx context.Context) time.Duration {
+	d := t.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		share := time.Until(deadline) / time.Duration(attemptsLeft(ctx))
+		if d <= 0 || share < d {
+			d = share
+		}
+	}
+	return d
+}
@@ -0,0 +1,77 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+)
+
+func sleepyProvider(delays ...time.Duration) (*MockProvider, *int32) {
+	var calls int32
+	return &MockProvider{
+		QuoteFn: func(ctx context.Context, symbol string) (*domain.Quote, error) {
+			i := atomic.AddInt32(&calls, 1) - 1
+			delay := delays[len(delays)-1]
+			if int(i) < len(delays) {
+				delay = delays[i]
+			}
+			select {
+			case <-time.After(delay):
+				return &domain.Quote{Price: domain.NewDecimalFromInt(1)}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}, &calls
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// 1. Attempt timeout is reported as a TimeoutError
+	mock, _ := sleepyProvider(time.Second)
+	to := decorators.NewTimeout(mock, 10*time.Millisecond)
+
+	_, err := to.GetQuote(ctx, "AAPL")
+	if !errors.Is(err, domain.ErrAttemptTimeout) {
+		t.Errorf("expected ErrAttemptTimeout, got %v", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Error("attempt timeout must not look like the caller's deadline")
+	}
+
+	// 2. The caller's deadline is reported as such
+	deadlineCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	to = decorators.NewTimeout(mock, time.Minute)
+	_, err = to.GetQuote(deadlineCtx, "AAPL")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, domain.ErrAttemptTimeout) {
+		t.Errorf("expected caller deadline, got %v", err)
+	}
+}
+
+func TestTimeoutWithRetryBudget(t *testing.T) {
+	// The first attempt hangs, the second answers quickly. With 3 attempts and
+	// 300ms overall, the first attempt only gets ~100ms.
+	mock, calls := sleepyProvider(time.Second, time.Millisecond)
+	r := decorators.NewRetry(decorators.NewTimeout(mock, 0), 2, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := r.GetQuote(ctx, "AAPL"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected the first attempt to be cut to its share, took %v", elapsed)
+	}
+	if *calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", *calls)
+	}
+}
@@ -0,0 +1,312 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode selects how Round and Div discard digits
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest neighbour, ties to the even one (banker's rounding)
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest neighbour, ties away from zero
+	RoundHalfUp
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundFloor rounds towards negative infinity
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity
+	RoundCeiling
+)
+
+// maxExponent bounds the exponent accepted by ParseDecimal, so a hostile "1e999999999"
+// cannot allocate an enormous coefficient
+const maxExponent = 1000
+
+var (
+	bigZero = new(big.Int)
+	bigTen  = big.NewInt(10)
+)
+
+// Decimal is an exact decimal number: an arbitrary-precision integer coefficient
+// scaled by a power of ten, so values such as 0.1 and 1e-9 are represented without
+// binary floating point error. The zero value is 0. Decimals are immutable and safe
+// to copy and share.
+//
+// The scale, the number of digits after the decimal point, is preserved: 1.50 stays
+// 1.50 when printed, but Equal and Cmp compare numerically. Decimals are encoded in
+// JSON as strings and decoded from strings or numbers.
+//
+// Decimals cannot be compared with ==, which would compare the coefficients' pointers;
+// use Equal or Cmp.
+type Decimal struct {
+	_     [0]func() // forbids ==
+	coef  *big.Int  // nil means 0; never modified once set
+	scale int32     // never negative
+}
+
+// NewDecimal returns unscaled * 10^-scale, e.g. NewDecimal(150, 2) is 1.50.
+// A negative scale is treated as 0.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	return Decimal{coef: big.NewInt(unscaled), scale: scale}
+}
+
+// NewDecimalFromInt returns v as a Decimal
+func NewDecimalFromInt(v int64) Decimal {
+	return Decimal{coef: big.NewInt(v)}
+}
+
+// NewDecimalFromFloat returns the shortest decimal that converts back to f, so
+// 0.1 becomes exactly 0.1. It panics if f is NaN or infinite.
+func NewDecimalFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(fmt.Sprintf("domain: cannot convert %v to Decimal", f))
+	}
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ParseDecimal parses a decimal in plain or exponent notation, such as "-12.50",
+// "1e-9" or "2.5E+3"
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > maxExponent || e < -maxExponent {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+	digits := intPart + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	coef, _ := new(big.Int).SetString(sign+digits, 10)
+	scale := len(frac) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if scale > maxExponent {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on invalid input.
+// It is meant for decimals written as literals.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// pow10 returns 10^n for n >= 0
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) coefficient() *big.Int {
+	if d.coef == nil {
+		return bigZero
+	}
+	return d.coef
+}
+
+// rescaled returns the coefficient of d expressed at a scale >= d.scale
+func (d Decimal) rescaled(scale int32) *big.Int {
+	c := new(big.Int).Set(d.coefficient())
+	if scale > d.scale {
+		c.Mul(c, pow10(int(scale-d.scale)))
+	}
+	return c
+}
+
+// align returns the coefficients of d and e at their common scale
+func align(d, e Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(d.scale, e.scale)
+	return d.rescaled(scale), e.rescaled(scale), scale
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Add returns d + e
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - e
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * e exactly; the scale is the sum of both scales
+func (d Decimal) Mul(e Decimal) Decimal {
+	c := new(big.Int).Mul(d.coefficient(), e.coefficient())
+	return Decimal{coef: c, scale: d.scale + e.scale}
+}
+
+// Div returns d / e with scale digits after the decimal point, rounded with mode.
+// It panics if e is zero.
+func (d Decimal) Div(e Decimal, scale int32, mode RoundingMode) Decimal {
+	if e.IsZero() {
+		panic("domain: division by zero")
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	// d/e * 10^scale = cd * 10^(se+scale) / (ce * 10^sd)
+	num := new(big.Int).Mul(d.coefficient(), pow10(int(e.scale+scale)))
+	den := new(big.Int).Mul(e.coefficient(), pow10(int(d.scale)))
+	return Decimal{coef: divRound(num, den, mode), scale: scale}
+}
+
+// Round returns d with exactly scale digits after the decimal point, rounding with
+// mode when digits are dropped and padding with zeros otherwise
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	if scale >= d.scale {
+		return Decimal{coef: d.rescaled(scale), scale: scale}
+	}
+	return Decimal{coef: divRound(d.coefficient(), pow10(int(d.scale-scale)), mode), scale: scale}
+}
+
+// divRound returns num / den rounded with mode
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// sign of the exact quotient; q is truncated towards zero
+	sign := int64(num.Sign() * den.Sign())
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmpHalf := half.Cmp(new(big.Int).Abs(den))
+
+	var away bool
+	switch mode {
+	case RoundHalfEven:
+		away = cmpHalf > 0 || (cmpHalf == 0 && q.Bit(0) == 1)
+	case RoundHalfUp:
+		away = cmpHalf >= 0
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeiling:
+		away = sign > 0
+	}
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.coefficient()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.coefficient()), scale: d.scale}
+}
+
+// Sign returns -1, 0 or 1
+func (d Decimal) Sign() int {
+	return d.coefficient().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than e
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and e are numerically equal, regardless of scale
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Float64 returns the nearest float64, for statistics and display only
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns d in plain notation with its full scale, e.g. "-0.000000001"
+func (d Decimal) String() string {
+	c := d.coefficient()
+	digits := new(big.Int).Abs(c).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if c.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalJSON encodes d as a string to keep every digit in JSON consumers that
+// decode numbers as floats
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a string or a number. null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for in, want := range map[string]string{
+		"0":        "0",
+		"12.50":    "12.50",
+		"-0.001":   "-0.001",
+		"+3":       "3",
+		".5":       "0.5",
+		"1e-9":     "0.000000001",
+		"2.5E+3":   "2500",
+		"1.23e1":   "12.3",
+		"-1.5e-2":  "-0.015",
+		"00042.10": "42.10",
+	} {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", in, err)
+			continue
+		}
+		if d.String() != want {
+			t.Errorf("%q: expected %s, got %s", in, want, d)
+		}
+	}
+
+	for _, in := range []string{"", "-", "abc", "1.2.3", "1e", "1e99999", " 1", "1_000", "--1", "0x10"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+
+	// The classic float drift does not happen
+	if sum := d("0.1").Add(d("0.2")); !sum.Equal(d("0.3")) || sum.String() != "0.3" {
+		t.Errorf("expected 0.3, got %s", sum)
+	}
+	if got := d("10").Sub(d("0.000000001")).String(); got != "9.999999999" {
+		t.Errorf("unexpected difference: %s", got)
+	}
+	if got := d("1.5").Mul(d("0.000000002")).String(); got != "0.0000000030" {
+		t.Errorf("unexpected product: %s", got)
+	}
+	if got := d("1").Div(d("3"), 4, RoundHalfEven).String(); got != "0.3333" {
+		t.Errorf("unexpected quotient: %s", got)
+	}
+	if got := d("-2").Div(d("3"), 2, RoundHalfUp).String(); got != "-0.67" {
+		t.Errorf("unexpected quotient: %s", got)
+	}
+	if !d("1.50").Equal(d("1.5")) || d("1.5").Cmp(d("1.49")) != 1 || d("-1").Cmp(d("0")) != -1 {
+		t.Error("unexpected comparison")
+	}
+	if NewDecimalFromFloat(0.1).String() != "0.1" || NewDecimal(150, 2).String() != "1.50" {
+		t.Error("unexpected constructor result")
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || !zero.Add(d("1")).Equal(d("1")) {
+		t.Error("zero value should be 0")
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"2.345", RoundHalfUp, "2.35"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"2.349", RoundDown, "2.34"},
+		{"-2.341", RoundDown, "-2.34"},
+		{"2.341", RoundUp, "2.35"},
+		{"-2.341", RoundFloor, "-2.35"},
+		{"2.341", RoundFloor, "2.34"},
+		{"-2.349", RoundCeiling, "-2.34"},
+		{"2.341", RoundCeiling, "2.35"},
+		{"2.3", RoundHalfEven, "2.30"},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.in).Round(2, tt.mode).String(); got != tt.want {
+			t.Errorf("Round(%s, %d): expected %s, got %s", tt.in, tt.mode, tt.want, got)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+		C Decimal `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": "0.000000001234567891", "b": 12345678901234567890.5, "c": null}`), &v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"a":"0.000000001234567891","b":"12345678901234567890.5","c":"0"}`; string(out) != want {
+		t.Errorf("expected %s, got %s", want, out)
+	}
+	if err := json.Unmarshal([]byte(`{"a": "1,5"}`), &v); err == nil {
+		t.Error("expected error for invalid decimal")
+	}
+}
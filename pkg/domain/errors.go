@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sentinel errors shared by providers, decorators and the client.
+// Match them with errors.Is; richer details are available through errors.As
+// on *HTTPError and *RateLimitError.
+var (
+	ErrSymbolNotFound      = errors.New("symbol not found")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrCircuitOpen         = errors.New("circuit breaker is open")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrProviderNotFound    = errors.New("provider not found")
+	ErrNotSupported        = errors.New("operation not supported by provider")
+	ErrBulkheadFull        = errors.New("bulkhead full")
+	ErrAttemptTimeout      = errors.New("attempt timed out")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrAmbiguousSymbol     = errors.New("ambiguous symbol")
+)
+
+// HTTPError is returned when an upstream API answers with a non-success status
+type HTTPError struct {
+	StatusCode int
+	Body       string // leading part of the response body, for diagnostics
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Body)
+}
+
+// Is maps the status code onto the matching sentinel error
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrSymbolNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUpstreamUnavailable:
+		return e.StatusCode >= 500
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// AuthError is returned when the upstream rejected the credentials, for example a
+// missing, invalid or expired API key, or a key used against the wrong plan
+type AuthError struct {
+	StatusCode int
+	Message    string // the upstream's explanation, when it gave one
+}
+
+func (e *AuthError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%v: status %d", ErrUnauthorized, e.StatusCode)
+	}
+	return fmt.Sprintf("%v: status %d: %s", ErrUnauthorized, e.StatusCode, e.Message)
+}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// RateLimitError is returned when the upstream rejected the request because of rate limits
+type RateLimitError struct {
+	RetryAfter time.Duration // zero when the upstream gave no hint
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	msg := ErrRateLimited.Error()
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// AmbiguousSymbolError is returned when a symbol matches several instruments and the
+// source was not allowed to pick one. It also matches ErrSymbolNotFound, so it is
+// treated like an unknown symbol: not retried and not counted by circuit breakers.
+type AmbiguousSymbolError struct {
+	Symbol     string
+	Candidates []string // canonical identifiers of the matching instruments
+}
+
+func (e *AmbiguousSymbolError) Error() string {
+	return fmt.Sprintf("%v: %s matches %s", ErrAmbiguousSymbol, e.Symbol, strings.Join(e.Candidates, ", "))
+}
+
+func (e *AmbiguousSymbolError) Is(target error) bool {
+	return target == ErrAmbiguousSymbol || target == ErrSymbolNotFound
+}
+
+// TimeoutError is returned when a single attempt ran out of its own time slice while
+// the caller's deadline had not passed yet. Unlike context.DeadlineExceeded it is safe
+// to retry.
+type TimeoutError struct {
+	Limit time.Duration // the time the attempt was allowed
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%v after %s", ErrAttemptTimeout, e.Limit)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrAttemptTimeout
+}
+
+// Timeout reports true, matching the net.Error convention
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// BatchError reports the symbols that could not be fetched in a batch request.
+// It is returned alongside the quotes that did succeed.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	symbols := make([]string, 0, len(e.Errors))
+	for s := range e.Errors {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	parts := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		parts = append(parts, fmt.Sprintf("%s: %v", s, e.Errors[s]))
+	}
+	return fmt.Sprintf("%d symbol(s) failed: %s", len(symbols), strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is/As to inspect the per-symbol errors
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidInstrument is returned when a unified symbol cannot be parsed
+var ErrInvalidInstrument = errors.New("invalid instrument")
+
+// Instrument identifies an asset independently of any provider. Its canonical form
+// is the unified symbol syntax understood by ParseInstrument:
+//
+//	<type>:[<exchange>:]<base>[/<quote>]
+//
+// for example crypto:BTC/USD, stock:AAPL or stock:XNAS:AAPL.
+type Instrument struct {
+	AssetType AssetType `json:"asset_type"`
+	// Base is the traded asset: a ticker such as AAPL or BTC
+	Base string `json:"base"`
+	// Quote is the currency the price is expressed in; empty means the provider's default
+	Quote string `json:"quote,omitempty"`
+	// Exchange is the ISO 10383 market identifier code (MIC), such as XNAS
+	Exchange string `json:"exchange,omitempty"`
+
+	ISIN string `json:"isin,omitempty"`
+	FIGI string `json:"figi,omitempty"`
+
+	// Codes maps a provider name, as registered with the client, to its native code
+	// for this instrument. A code takes precedence over the provider's own translation.
+	Codes map[string]string `json:"codes,omitempty"`
+}
+
+// assetTypes lists the known asset types
+var assetTypes = []AssetType{AssetTypeStock, AssetTypeCrypto}
+
+// ParseAssetType matches s case-insensitively against the known asset types
+func ParseAssetType(s string) (AssetType, bool) {
+	for _, t := range assetTypes {
+		if strings.EqualFold(s, string(t)) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// ParseInstrument parses the unified symbol syntax. Parts are case-insensitive and
+// normalised to upper case.
+func ParseInstrument(s string) (Instrument, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Instrument{}, fmt.Errorf("%w: %q: expected type:[exchange:]symbol", ErrInvalidInstrument, s)
+	}
+
+	var inst Instrument
+	var ok bool
+	if inst.AssetType, ok = ParseAssetType(parts[0]); !ok {
+		return Instrument{}, fmt.Errorf("%w: %q: unknown asset type %q", ErrInvalidInstrument, s, parts[0])
+	}
+
+	if len(parts) == 3 {
+		inst.Exchange = strings.ToUpper(parts[1])
+		if inst.Exchange == "" {
+			return Instrument{}, fmt.Errorf("%w: %q: empty exchange", ErrInvalidInstrument, s)
+		}
+	}
+
+	symbol := parts[len(parts)-1]
+	if base, quote, ok := strings.Cut(symbol, "/"); ok {
+		inst.Base, inst.Quote = strings.ToUpper(base), strings.ToUpper(quote)
+		if inst.Quote == "" {
+			return Instrument{}, fmt.Errorf("%w: %q: empty quote currency", ErrInvalidInstrument, s)
+		}
+	} else {
+		inst.Base = strings.ToUpper(symbol)
+	}
+	if inst.Base == "" || strings.ContainsAny(inst.Base, " /") {
+		return Instrument{}, fmt.Errorf("%w: %q: invalid symbol %q", ErrInvalidInstrument, s, symbol)
+	}
+	return inst, nil
+}
+
+// MustParseInstrument is like ParseInstrument but panics on invalid input.
+// It is meant for instruments written as literals.
+func MustParseInstrument(s string) Instrument {
+	inst, err := ParseInstrument(s)
+	if err != nil {
+		panic(err)
+	}
+	return inst
+}
+
+// String returns the unified symbol, e.g. crypto:BTC/USD
+func (i Instrument) String() string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(string(i.AssetType)))
+	b.WriteByte(':')
+	if i.Exchange != "" {
+		b.WriteString(i.Exchange)
+		b.WriteByte(':')
+	}
+	b.WriteString(i.Base)
+	if i.Quote != "" {
+		b.WriteByte('/')
+		b.WriteString(i.Quote)
+	}
+	return b.String()
+}
+
+// Coverage describes the instruments a provider can price
+type Coverage struct {
+	AssetTypes []AssetType `json:"asset_types"`
+	// Exchanges lists the supported MICs; empty means any exchange
+	Exchanges []string `json:"exchanges,omitempty"`
+}
+
+// Covers reports whether inst has a supported asset type and, when it names an
+// exchange, a supported exchange
+func (c Coverage) Covers(inst Instrument) bool {
+	if !slices.Contains(c.AssetTypes, inst.AssetType) {
+		return false
+	}
+	return inst.Exchange == "" || len(c.Exchanges) == 0 || slices.Contains(c.Exchanges, inst.Exchange)
+}
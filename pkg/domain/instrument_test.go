@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseInstrument(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Instrument
+		str  string
+	}{
+		{"crypto:BTC/USD", Instrument{AssetType: AssetTypeCrypto, Base: "BTC", Quote: "USD"}, "crypto:BTC/USD"},
+		{"Crypto:eth", Instrument{AssetType: AssetTypeCrypto, Base: "ETH"}, "crypto:ETH"},
+		{"stock:XNAS:AAPL", Instrument{AssetType: AssetTypeStock, Base: "AAPL", Exchange: "XNAS"}, "stock:XNAS:AAPL"},
+		{"stock:brk.b", Instrument{AssetType: AssetTypeStock, Base: "BRK.B"}, "stock:BRK.B"},
+		{" stock:xlon:vod/gbp ", Instrument{AssetType: AssetTypeStock, Base: "VOD", Quote: "GBP", Exchange: "XLON"}, "stock:XLON:VOD/GBP"},
+	}
+	for _, tt := range tests {
+		got, err := ParseInstrument(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got.AssetType != tt.want.AssetType || got.Base != tt.want.Base || got.Quote != tt.want.Quote || got.Exchange != tt.want.Exchange {
+			t.Errorf("%q: expected %+v, got %+v", tt.in, tt.want, got)
+		}
+		if got.String() != tt.str {
+			t.Errorf("%q: expected String() %q, got %q", tt.in, tt.str, got.String())
+		}
+	}
+
+	for _, in := range []string{"", "AAPL", "bond:US10Y", "stock::AAPL", "stock:XNAS:", "crypto:BTC/", "crypto:/USD", "stock:a:b:c"} {
+		if _, err := ParseInstrument(in); !errors.Is(err, ErrInvalidInstrument) {
+			t.Errorf("%q: expected ErrInvalidInstrument, got %v", in, err)
+		}
+	}
+}
@@ -14,10 +14,105 @@ const (
 
 // Quote represents a simplified pricing quote for an asset
 type Quote struct {
-	Symbol      string    `json:"symbol"`
-	Price       float64   `json:"price"`
-	Change24h   float64   `json:"change_24h,omitempty"`
-	Volume      float64   `json:"volume,omitempty"`
+	// Symbol is the symbol as requested
+	Symbol string `json:"symbol"`
+	// CanonicalSymbol is the identifier the source resolved Symbol to, such as a
+	// CoinGecko id. It is empty when the source used Symbol as given.
+	CanonicalSymbol string `json:"canonical_symbol,omitempty"`
+
+	Price       Decimal   `json:"price"`
+	Change24h   Decimal   `json:"change_24h,omitzero"`
+	Volume      Decimal   `json:"volume,omitzero"`
 	LastUpdated time.Time `json:"last_updated"`
 	Source      string    `json:"source"`
+
+	// Consensus is set when the quote was aggregated from several sources
+	Consensus *Consensus `json:"consensus,omitempty"`
+}
+
+// Consensus describes how an aggregated quote was derived
+type Consensus struct {
+	Method     string             `json:"method"`
+	Sources    []string           `json:"sources"`
+	Outliers   []string           `json:"outliers,omitempty"`
+	Prices     map[string]Decimal `json:"prices"`
+	Dispersion float64            `json:"dispersion"` // relative standard deviation of the accepted prices
+}
+
+// Clone returns a deep copy of the quote
+func (q *Quote) Clone() *Quote {
+	if q == nil {
+		return nil
+	}
+	cp := *q
+	if q.Consensus != nil {
+		c := *q.Consensus
+		c.Sources = append([]string(nil), q.Consensus.Sources...)
+		c.Outliers = append([]string(nil), q.Consensus.Outliers...)
+		c.Prices = make(map[string]Decimal, len(q.Consensus.Prices))
+		for k, v := range q.Consensus.Prices {
+			c.Prices[k] = v
+		}
+		cp.Consensus = &c
+	}
+	return &cp
+}
+
+// Interval is the width of a single candle in a historical series
+type Interval string
+
+const (
+	Interval1m  Interval = "1m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval4h  Interval = "4h"
+	Interval1d  Interval = "1d"
+	Interval1w  Interval = "1w"
+)
+
+// Duration returns the length of the interval, or 0 if the interval is unknown
+func (i Interval) Duration() time.Duration {
+	switch i {
+	case Interval1m:
+		return time.Minute
+	case Interval5m:
+		return 5 * time.Minute
+	case Interval15m:
+		return 15 * time.Minute
+	case Interval30m:
+		return 30 * time.Minute
+	case Interval1h:
+		return time.Hour
+	case Interval4h:
+		return 4 * time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	case Interval1w:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Candle represents an OHLCV bar for a single interval
+type Candle struct {
+	Symbol   string    `json:"symbol"`
+	Interval Interval  `json:"interval"`
+	Start    time.Time `json:"start"`
+	Open     Decimal   `json:"open"`
+	High     Decimal   `json:"high"`
+	Low      Decimal   `json:"low"`
+	Close    Decimal   `json:"close"`
+	Volume   Decimal   `json:"volume,omitzero"`
+	Source   string    `json:"source"`
+}
+
+// RateLimitInfo is the rate limit state reported by an upstream API on a response
+type RateLimitInfo struct {
+	StatusCode int
+	Limit      int           // requests allowed per window, -1 if unknown
+	Remaining  int           // requests left in the current window, -1 if unknown
+	Reset      time.Duration // time until the window resets, 0 if unknown
+	RetryAfter time.Duration // explicit back-off requested by the upstream
 }
@@ -0,0 +1,31 @@
+package ports
+
+import (
+	"context"
+	"markets-sdk/pkg/domain"
+)
+
+type rateLimitObserverKey struct{}
+
+// RateLimitObserver receives rate limit signals seen by a provider while serving a call
+type RateLimitObserver func(domain.RateLimitInfo)
+
+// WithRateLimitObserver returns a context whose calls report rate limit signals to fn.
+// Observers already present in ctx keep receiving them.
+func WithRateLimitObserver(ctx context.Context, fn RateLimitObserver) context.Context {
+	if parent, ok := ctx.Value(rateLimitObserverKey{}).(RateLimitObserver); ok {
+		inner := fn
+		fn = func(info domain.RateLimitInfo) {
+			inner(info)
+			parent(info)
+		}
+	}
+	return context.WithValue(ctx, rateLimitObserverKey{}, fn)
+}
+
+// ReportRateLimit is called by providers to forward rate limit signals to the observers in ctx
+func ReportRateLimit(ctx context.Context, info domain.RateLimitInfo) {
+	if fn, ok := ctx.Value(rateLimitObserverKey{}).(RateLimitObserver); ok {
+		fn(info)
+	}
+}
@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"markets-sdk/pkg/domain"
+	"time"
 )
 
 // Provider defines the interface for fetching market data
@@ -10,3 +11,91 @@ type Provider interface {
 	// GetQuote returns the latest quote for a given symbol
 	GetQuote(ctx context.Context, symbol string) (*domain.Quote, error)
 }
+
+// HistoricalProvider is implemented by providers that can return historical OHLCV data
+type HistoricalProvider interface {
+	// GetHistory returns the candles for symbol in [from, to), oldest first
+	GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error)
+}
+
+// BatchProvider is implemented by providers that can fetch several quotes in one call
+type BatchProvider interface {
+	// GetQuotes returns the quotes keyed by the requested symbol. Symbols that could not
+	// be fetched are reported through a *domain.BatchError alongside the partial result.
+	GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error)
+}
+
+// StreamingProvider is implemented by providers that can push quotes as they change
+type StreamingProvider interface {
+	// Subscribe starts streaming quotes for symbols. The returned channel is closed
+	// when ctx ends, when the symbols are unsubscribed or when the upstream feed drops.
+	Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error)
+	// Unsubscribe stops streaming the given symbols
+	Unsubscribe(ctx context.Context, symbols []string) error
+}
+
+// SymbolTranslator is implemented by providers that can translate a canonical
+// instrument into their native symbol, e.g. crypto:BTC/USD into Yahoo's BTC-USD
+type SymbolTranslator interface {
+	// NativeSymbol returns the symbol to pass to the provider's methods. Instruments
+	// the provider cannot serve fail with domain.ErrNotSupported.
+	NativeSymbol(inst domain.Instrument) (string, error)
+}
+
+// Capabilities describes what a provider can do
+type Capabilities struct {
+	// Batch reports native multi-symbol requests through BatchProvider
+	Batch bool `json:"batch"`
+	// MaxBatchSize is the number of symbols sent per upstream request; 0 when unknown
+	MaxBatchSize int `json:"max_batch_size,omitempty"`
+	// History reports HistoricalProvider support for Intervals
+	History   bool              `json:"history"`
+	Intervals []domain.Interval `json:"intervals,omitempty"`
+	// Streaming reports StreamingProvider support
+	Streaming bool `json:"streaming"`
+	// Volume reports whether GetQuote populates Quote.Volume
+	Volume bool `json:"volume"`
+	// Coverage lists the asset types and exchanges served, used for routing
+	Coverage domain.Coverage `json:"coverage"`
+}
+
+// CapabilityProvider is implemented by providers that describe their capabilities.
+// Decorators implement it by forwarding to the provider they wrap.
+//
+// Decorators also implement every optional interface, returning domain.ErrNotSupported
+// when the provider they wrap does not, so use the As functions below rather than a
+// bare type assertion to find out what a provider supports.
+type CapabilityProvider interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns p's declared capabilities, or for providers that declare
+// none, the optional interfaces p implements
+func CapabilitiesOf(p Provider) Capabilities {
+	if cp, ok := p.(CapabilityProvider); ok {
+		return cp.Capabilities()
+	}
+	var c Capabilities
+	_, c.Batch = p.(BatchProvider)
+	_, c.History = p.(HistoricalProvider)
+	_, c.Streaming = p.(StreamingProvider)
+	return c
+}
+
+// AsBatchProvider returns p as a BatchProvider if it batches natively
+func AsBatchProvider(p Provider) (BatchProvider, bool) {
+	bp, ok := p.(BatchProvider)
+	return bp, ok && CapabilitiesOf(p).Batch
+}
+
+// AsHistoricalProvider returns p as a HistoricalProvider if it serves history
+func AsHistoricalProvider(p Provider) (HistoricalProvider, bool) {
+	hp, ok := p.(HistoricalProvider)
+	return hp, ok && CapabilitiesOf(p).History
+}
+
+// AsStreamingProvider returns p as a StreamingProvider if it streams quotes
+func AsStreamingProvider(p Provider) (StreamingProvider, bool) {
+	sp, ok := p.(StreamingProvider)
+	return sp, ok && CapabilitiesOf(p).Streaming
+}
@@ -0,0 +1,20 @@
+package coingecko
+
+import (
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Capabilities reports batch quotes, 24h volume for crypto assets on any venue and
+// history in the intervals market_chart samples can fill: hourly ones for ranges up
+// to 90 days, daily and weekly for any range. See GetHistory.
+func (p *Provider) Capabilities() ports.Capabilities {
+	return ports.Capabilities{
+		Batch:        true,
+		MaxBatchSize: maxBatchSize,
+		History:      true,
+		Intervals:    []domain.Interval{domain.Interval1h, domain.Interval4h, domain.Interval1d, domain.Interval1w},
+		Volume:       true,
+		Coverage:     domain.Coverage{AssetTypes: []domain.AssetType{domain.AssetTypeCrypto}},
+	}
+}
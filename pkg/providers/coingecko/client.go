@@ -4,17 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/providers/internal/httpx"
 )
 
-const baseURL = "https://api.coingecko.com/api/v3"
+// maxBatchSize bounds the number of ids sent in a single /simple/price request
+const maxBatchSize = 100
 
 // Buffer pool to reduce GC pressure when reading response bodies
 var bufPool = sync.Pool{
@@ -24,42 +29,316 @@ var bufPool = sync.Pool{
 }
 
 type Provider struct {
-	client *http.Client
+	client    *http.Client
+	baseURL   string
+	userAgent string
+	apiKey    string
+	plan      Plan
+	registry  *Registry
 }
 
-func NewProvider() *Provider {
-	return &Provider{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+func NewProvider(opts ...Option) *Provider {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
+	if o.apiKey == "" {
+		o.plan = PlanPublic
+	}
+	if o.baseURL == "" {
+		o.baseURL = o.plan.baseURL()
+	}
+	p := &Provider{
+		client:    httpx.ClientConfig{Client: o.client, Transport: o.transport, Timeout: o.timeout}.Build(),
+		baseURL:   o.baseURL,
+		userAgent: o.userAgent,
+		apiKey:    o.apiKey,
+		plan:      o.plan,
+	}
+	if o.registry {
+		p.registry = newRegistry(p.get, p.baseURL, o.registryOpts...)
+	}
+	return p
+}
+
+// Plan returns the API plan the provider was configured for
+func (p *Provider) Plan() Plan {
+	return p.plan
+}
+
+// Registry returns the symbol registry, or nil when WithRegistry was not given
+func (p *Provider) Registry() *Registry {
+	return p.registry
+}
+
+// resolve maps a requested symbol to a CoinGecko id
+func (p *Provider) resolve(ctx context.Context, symbol string) (string, error) {
+	if p.registry == nil {
+		return strings.ToLower(symbol), nil
+	}
+	return p.registry.Resolve(ctx, symbol)
+}
+
+// canonical returns the id a symbol was resolved to, or "" when the symbol was sent
+// as given because no registry is configured or it already was the id
+func (p *Provider) canonical(symbol, id string) string {
+	if p.registry == nil || symbol == id {
+		return ""
+	}
+	return id
+}
+
+// simplePriceURL builds the /simple/price request for ids
+func (p *Provider) simplePriceURL(ids []string) string {
+	query := url.Values{
+		"ids":                 {strings.Join(ids, ",")},
+		"vs_currencies":       {"usd"},
+		"include_24hr_vol":    {"true"},
+		"include_24hr_change": {"true"},
+	}
+	return p.baseURL + "/simple/price?" + query.Encode()
 }
 
 // simplePriceResponse matches the structure returned by /simple/price
+// Numbers are kept as json.Number so prices of small-cap tokens keep every digit.
 type simplePriceResponse map[string]struct {
-	USD           float64 `json:"usd"`
-	USD24hChange  float64 `json:"usd_24h_change"`
-	USD24hVol     float64 `json:"usd_24h_vol"`
-	LastUpdatedAt float64 `json:"last_updated_at"`
+	USD           json.Number `json:"usd"`
+	USD24hChange  json.Number `json:"usd_24h_change"`
+	USD24hVol     json.Number `json:"usd_24h_vol"`
+	LastUpdatedAt json.Number `json:"last_updated_at"`
+}
+
+// marketChartResponse matches the prices returned by /coins/{id}/market_chart/range.
+// Each point is a [unix millis, value] pair.
+type marketChartResponse struct {
+	Prices [][2]json.Number `json:"prices"`
 }
 
 func (p *Provider) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
-	id := strings.ToLower(symbol)
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd&include_24hr_vol=true&include_24hr_change=true", baseURL, id)
+	id, err := p.resolve(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	var data simplePriceResponse
+	if err := p.get(ctx, p.simplePriceURL([]string{id}), &data); err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	item, ok := data[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, symbol)
+	}
+
+	var nums httpx.Numbers
+	q := &domain.Quote{
+		Symbol:          symbol,
+		CanonicalSymbol: p.canonical(symbol, id),
+		Price:           nums.Decimal(item.USD),
+		Change24h:       nums.Decimal(item.USD24hChange),
+		Volume:          nums.Decimal(item.USD24hVol),
+		LastUpdated:     time.Now(),
+		Source:          "coingecko",
+	}
+	if nums.Err != nil {
+		return nil, nums.Err
+	}
+	return q, nil
+}
+
+// GetQuotes fetches several quotes using the comma-separated ids of /simple/price.
+// Symbols resolving to the same id share one entry, and large batches are split
+// into requests of at most maxBatchSize ids.
+func (p *Provider) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	quotes := make(map[string]*domain.Quote, len(symbols))
+	failed := make(map[string]error)
+
+	requested := make(map[string][]string, len(symbols)) // id -> symbols
+	var ids []string
+	for _, s := range symbols {
+		id, err := p.resolve(ctx, s)
+		if err != nil {
+			failed[s] = err
+			continue
+		}
+		if _, ok := requested[id]; !ok {
+			ids = append(ids, id)
+		}
+		requested[id] = append(requested[id], s)
+	}
+
+	for start := 0; start < len(ids); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		chunk := ids[start:end]
+
+		var data simplePriceResponse
+		if err := p.get(ctx, p.simplePriceURL(chunk), &data); err != nil {
+			for _, id := range chunk {
+				for _, s := range requested[id] {
+					failed[s] = err
+				}
+			}
+			continue
+		}
+
+		now := time.Now()
+		for _, id := range chunk {
+			item, ok := data[id]
+			for _, s := range requested[id] {
+				if !ok {
+					failed[s] = fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, s)
+					continue
+				}
+				var nums httpx.Numbers
+				q := &domain.Quote{
+					Symbol:          s,
+					CanonicalSymbol: p.canonical(s, id),
+					Price:           nums.Decimal(item.USD),
+					Change24h:       nums.Decimal(item.USD24hChange),
+					Volume:          nums.Decimal(item.USD24hVol),
+					LastUpdated:     now,
+					Source:          "coingecko",
+				}
+				if nums.Err != nil {
+					failed[s] = nums.Err
+					continue
+				}
+				quotes[s] = q
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+// GetHistory returns candles built from the price samples of the market_chart/range
+// endpoint. CoinGecko picks the sample granularity from the range: 5 minutes for the
+// last day, hourly for ranges up to 90 days and daily beyond. Intervals finer than the
+// granularity of the range fail with domain.ErrNotSupported. CoinGecko only reports a
+// rolling 24h volume, not the volume traded within a candle, so Volume is left zero.
+func (p *Provider) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	if interval.Duration() == 0 {
+		return nil, fmt.Errorf("%w: interval %s", domain.ErrNotSupported, interval)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("invalid time range: %s - %s", from, to)
+	}
+	if g := granularity(from, to, time.Now()); interval.Duration() < g {
+		return nil, fmt.Errorf("%w: interval %s is finer than the %s samples CoinGecko returns for this range", domain.ErrNotSupported, interval, g)
+	}
+
+	id, err := p.resolve(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
+	query := url.Values{
+		"vs_currency": {"usd"},
+		"from":        {strconv.FormatInt(from.Unix(), 10)},
+		"to":          {strconv.FormatInt(to.Unix(), 10)},
+	}
+	endpoint := fmt.Sprintf("%s/coins/%s/market_chart/range?%s", p.baseURL, url.PathEscape(id), query.Encode())
+
+	var data marketChartResponse
+	if err := p.get(ctx, endpoint, &data); err != nil {
+		return nil, err
+	}
+
+	return buildCandles(symbol, interval, data)
+}
+
+// granularity returns the spacing of the market_chart/range samples for a range
+func granularity(from, to, now time.Time) time.Duration {
+	switch span := to.Sub(from); {
+	case span <= 24*time.Hour && now.Sub(from) <= 24*time.Hour:
+		return 5 * time.Minute
+	case span <= 90*24*time.Hour:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// buildCandles groups price samples into interval-aligned buckets
+func buildCandles(symbol string, interval domain.Interval, data marketChartResponse) ([]domain.Candle, error) {
+	d := interval.Duration()
+	var nums httpx.Numbers
+
+	var candles []domain.Candle
+	for _, pt := range data.Prices {
+		ms, err := millis(pt[0])
+		if err != nil {
+			return nil, err
+		}
+		ts := time.UnixMilli(ms).UTC()
+		price := nums.Decimal(pt[1])
+		start := ts.Truncate(d)
+
+		n := len(candles)
+		if n == 0 || !candles[n-1].Start.Equal(start) {
+			candles = append(candles, domain.Candle{
+				Symbol:   symbol,
+				Interval: interval,
+				Start:    start,
+				Open:     price,
+				High:     price,
+				Low:      price,
+				Close:    price,
+				Source:   "coingecko",
+			})
+			n++
+		}
+
+		c := &candles[n-1]
+		if price.Cmp(c.High) > 0 {
+			c.High = price
+		}
+		if price.Cmp(c.Low) < 0 {
+			c.Low = price
+		}
+		c.Close = price
+	}
+	if nums.Err != nil {
+		return nil, nums.Err
+	}
+	return candles, nil
+}
+
+// millis parses a unix millisecond timestamp, which CoinGecko may send as a float
+func millis(n json.Number) (int64, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("malformed timestamp in response: %w", err)
+	}
+	return int64(f), nil
+}
+
+// get performs a GET request and decodes the JSON body into v
+func (p *Provider) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+	if h := p.plan.keyHeader(); h != "" {
+		req.Header.Set(h, p.apiKey)
+	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, err
+		return httpx.WrapTransportError(ctx, err)
 	}
 	defer resp.Body.Close()
+	httpx.ObserveRateLimit(ctx, resp)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if err := httpx.CheckResponse(resp); err != nil {
+		return authError(err)
 	}
 
 	// Use pooled buffer to read body. This allows us to have the body ensuring
@@ -69,25 +348,34 @@ func (p *Provider) GetQuote(ctx context.Context, symbol string) (*domain.Quote,
 	defer bufPool.Put(buf)
 
 	if _, err := io.Copy(buf, resp.Body); err != nil {
-		return nil, err
+		return err
 	}
 
-	var data simplePriceResponse
-	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
-		return nil, fmt.Errorf("failed to decode json: %w. body: %s", err, buf.String())
+	if err := json.Unmarshal(buf.Bytes(), v); err != nil {
+		return fmt.Errorf("failed to decode json: %w. body: %s", err, buf.String())
 	}
+	return nil
+}
 
-	item, ok := data[id]
-	if !ok {
-		return nil, fmt.Errorf("symbol %s not found in response", symbol)
-	}
+// errorResponse is the body CoinGecko sends with authentication failures
+type errorResponse struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+}
 
-	return &domain.Quote{
-		Symbol:      symbol,
-		Price:       item.USD,
-		Change24h:   item.USD24hChange,
-		Volume:      item.USD24hVol,
-		LastUpdated: time.Now(),
-		Source:      "coingecko",
-	}, nil
+// authError turns 401 and 403 responses into a *domain.AuthError carrying
+// CoinGecko's explanation, such as a missing key or a key used on the wrong host
+func authError(err error) error {
+	var httpErr *domain.HTTPError
+	if !errors.As(err, &httpErr) || !errors.Is(httpErr, domain.ErrUnauthorized) {
+		return err
+	}
+	authErr := &domain.AuthError{StatusCode: httpErr.StatusCode, Message: httpErr.Body}
+	var body errorResponse
+	if json.Unmarshal([]byte(httpErr.Body), &body) == nil && body.Status.ErrorMessage != "" {
+		authErr.Message = body.Status.ErrorMessage
+	}
+	return authErr
 }
@@ -0,0 +1,218 @@
+package coingecko
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+func TestBuildCandles(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(d time.Duration) json.Number { return json.Number(strconv.FormatInt(base.Add(d).UnixMilli(), 10)) }
+
+	data := marketChartResponse{
+		Prices: [][2]json.Number{
+			{ms(0), "10"},
+			{ms(20 * time.Minute), "12"},
+			{ms(40 * time.Minute), "9"},
+			{ms(time.Hour), "0.000000012345678901234567"},
+		},
+	}
+
+	candles, err := buildCandles("bitcoin", domain.Interval1h, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(candles))
+	}
+
+	c := candles[0]
+	if !c.Start.Equal(base) {
+		t.Errorf("expected start %v, got %v", base, c.Start)
+	}
+	if !c.Open.Equal(domain.NewDecimalFromInt(10)) || !c.High.Equal(domain.NewDecimalFromInt(12)) || !c.Low.Equal(domain.NewDecimalFromInt(9)) || !c.Close.Equal(domain.NewDecimalFromInt(9)) || !c.Volume.IsZero() {
+		t.Errorf("unexpected first candle: %+v", c)
+	}
+	// Digits beyond float64 precision survive parsing
+	if candles[1].Open.String() != "0.000000012345678901234567" {
+		t.Errorf("unexpected second candle: %+v", candles[1])
+	}
+}
+
+func TestGetHistoryGranularity(t *testing.T) {
+	now := time.Now()
+	p := NewProvider(WithBaseURL("http://127.0.0.1:0"))
+
+	// Intervals finer than the samples of the range are refused before any request
+	for _, tc := range []struct {
+		interval domain.Interval
+		from     time.Time
+	}{
+		{domain.Interval1m, now.Add(-time.Hour)},
+		{domain.Interval15m, now.Add(-7 * 24 * time.Hour)},
+		{domain.Interval4h, now.Add(-365 * 24 * time.Hour)},
+	} {
+		if _, err := p.GetHistory(context.Background(), "bitcoin", tc.interval, tc.from, now); !errors.Is(err, domain.ErrNotSupported) {
+			t.Errorf("%s from %v: expected ErrNotSupported, got %v", tc.interval, now.Sub(tc.from), err)
+		}
+	}
+
+	if g := granularity(now.Add(-time.Hour), now, now); g != 5*time.Minute {
+		t.Errorf("expected 5 minute samples for the last hour, got %v", g)
+	}
+	if g := granularity(now.Add(-30*24*time.Hour), now.Add(-29*24*time.Hour), now); g != time.Hour {
+		t.Errorf("expected hourly samples for a past day, got %v", g)
+	}
+}
+
+func TestProviderOptions(t *testing.T) {
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		switch r.URL.Query().Get("ids") {
+		case "bitcoin":
+			fmt.Fprint(w, `{"bitcoin":{"usd":50000,"usd_24h_change":1.5,"usd_24h_vol":1000,"last_updated_at":1700000000}}`)
+		case "bitcoin,nope":
+			fmt.Fprint(w, `{"bitcoin":{"usd":50000}}`)
+		case "limited":
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	base := &http.Client{Timeout: time.Minute}
+	p := NewProvider(
+		WithHTTPClient(base),
+		WithTimeout(time.Second),
+		WithBaseURL(srv.URL+"/"),
+		WithUserAgent("markets-test"),
+	)
+	if base.Timeout != time.Minute {
+		t.Errorf("caller's client was modified: timeout %v", base.Timeout)
+	}
+	ctx := context.Background()
+
+	// 1. Quote from the configured host with the configured User-Agent
+	q, err := p.GetQuote(ctx, "BITCOIN")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Price.Equal(domain.NewDecimalFromInt(50000)) || !q.Change24h.Equal(domain.MustParseDecimal("1.5")) || !q.Volume.Equal(domain.NewDecimalFromInt(1000)) {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+	if gotUA != "markets-test" {
+		t.Errorf("expected User-Agent markets-test, got %q", gotUA)
+	}
+	if q.CanonicalSymbol != "" {
+		t.Errorf("expected no canonical symbol without a registry, got %q", q.CanonicalSymbol)
+	}
+
+	// 2. Unknown ids are reported per symbol
+	quotes, err := p.GetQuotes(ctx, []string{"bitcoin", "nope"})
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["nope"], domain.ErrSymbolNotFound) {
+		t.Fatalf("expected not found for nope, got %v", err)
+	}
+	if len(quotes) != 1 || !quotes["bitcoin"].Price.Equal(domain.NewDecimalFromInt(50000)) {
+		t.Errorf("unexpected quotes: %v", quotes)
+	}
+
+	// 3. Rate limiting carries Retry-After
+	_, err = p.GetQuote(ctx, "limited")
+	var rl *domain.RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter != 3*time.Second {
+		t.Errorf("expected rate limit error with 3s Retry-After, got %v", err)
+	}
+}
+
+func TestProviderAPIKey(t *testing.T) {
+	// 1. The plan selects the host
+	if got := NewProvider().baseURL; got != publicBaseURL {
+		t.Errorf("expected public host, got %s", got)
+	}
+	if got := NewProvider(WithAPIKey("k", PlanDemo)).baseURL; got != publicBaseURL {
+		t.Errorf("expected public host for demo, got %s", got)
+	}
+	if got := NewProvider(WithAPIKey("k", PlanPro)).baseURL; got != proBaseURL {
+		t.Errorf("expected pro host, got %s", got)
+	}
+
+	// 2. The plan selects the header; rejected keys surface as AuthError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-cg-pro-api-key") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":{"error_code":10002,"error_message":"API Key Missing"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"bitcoin":{"usd":50000}}`)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	p := NewProvider(WithAPIKey("good", PlanPro), WithBaseURL(srv.URL))
+	if _, err := p.GetQuote(ctx, "bitcoin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p = NewProvider(WithAPIKey("good", PlanDemo), WithBaseURL(srv.URL))
+	_, err := p.GetQuote(ctx, "bitcoin")
+	var authErr *domain.AuthError
+	if !errors.As(err, &authErr) || authErr.StatusCode != http.StatusUnauthorized || authErr.Message != "API Key Missing" {
+		t.Errorf("expected AuthError with upstream message, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestNativeSymbol(t *testing.T) {
+	btc := domain.MustParseInstrument("crypto:BTC/USD")
+
+	if _, err := NewProvider().NativeSymbol(btc); !errors.Is(err, domain.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported without registry, got %v", err)
+	}
+
+	p := NewProvider(WithRegistry())
+	if got, err := p.NativeSymbol(btc); err != nil || got != "BTC" {
+		t.Errorf("expected BTC, got %q, %v", got, err)
+	}
+	for _, in := range []string{"crypto:BTC/EUR", "stock:AAPL"} {
+		if _, err := p.NativeSymbol(domain.MustParseInstrument(in)); !errors.Is(err, domain.ErrNotSupported) {
+			t.Errorf("%s: expected ErrNotSupported, got %v", in, err)
+		}
+	}
+}
+
+func TestProviderEscapesSymbols(t *testing.T) {
+	var gotIDs, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		gotPath = r.URL.EscapedPath()
+		fmt.Fprint(w, `{"prices":[]}`)
+	}))
+	defer srv.Close()
+
+	p := NewProvider(WithBaseURL(srv.URL))
+	_, _ = p.GetQuote(context.Background(), "a&vs_currencies=eur")
+	if gotIDs != "a&vs_currencies=eur" {
+		t.Errorf("expected the symbol to stay one query value, got %q", gotIDs)
+	}
+
+	now := time.Now()
+	_, _ = p.GetHistory(context.Background(), "a/b?c", domain.Interval1d, now.Add(-365*24*time.Hour), now)
+	if gotPath != "/coins/a%2Fb%3Fc/market_chart/range" {
+		t.Errorf("expected the symbol to stay one path segment, got %q", gotPath)
+	}
+}
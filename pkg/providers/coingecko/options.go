@@ -0,0 +1,80 @@
+package coingecko
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Provider
+type Option func(*options)
+
+type options struct {
+	client    *http.Client
+	transport http.RoundTripper
+	timeout   time.Duration
+	baseURL   string
+	userAgent string
+	apiKey    string
+	plan      Plan
+
+	registry     bool
+	registryOpts []RegistryOption
+}
+
+// WithHTTPClient uses client for every request. The client is copied, so later
+// WithTimeout or WithTransport options do not modify it.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTransport replaces the HTTP transport, e.g. to route through a proxy
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithBaseURL points the provider at another host, such as a caching proxy or an
+// httptest.Server. Defaults to the host of the plan.
+func WithBaseURL(url string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithAPIKey authenticates every request with key. The plan selects the host and
+// the header: Demo keys are sent to the public host as x-cg-demo-api-key, Pro keys
+// to pro-api.coingecko.com as x-cg-pro-api-key.
+func WithAPIKey(key string, plan Plan) Option {
+	return func(o *options) {
+		o.apiKey = key
+		o.plan = plan
+	}
+}
+
+// WithRegistry resolves symbols through a Registry built from the /coins/list
+// catalog, so tickers such as BTC, names and contract addresses work besides ids.
+// Without it symbols are used as CoinGecko ids.
+func WithRegistry(opts ...RegistryOption) Option {
+	return func(o *options) {
+		o.registry = true
+		o.registryOpts = opts
+	}
+}
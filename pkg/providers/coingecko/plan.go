@@ -0,0 +1,79 @@
+package coingecko
+
+import (
+	"fmt"
+	"time"
+)
+
+// Plan is the CoinGecko API plan a key belongs to. It selects the host, the header
+// carrying the key and the default rate limit.
+type Plan int
+
+const (
+	// PlanPublic is the anonymous public API; no key is sent
+	PlanPublic Plan = iota
+	// PlanDemo is the free Demo plan, served by the public host
+	PlanDemo
+	// PlanPro covers the paid plans, served by pro-api.coingecko.com
+	PlanPro
+)
+
+const (
+	publicBaseURL = "https://api.coingecko.com/api/v3"
+	proBaseURL    = "https://pro-api.coingecko.com/api/v3"
+)
+
+func (p Plan) String() string {
+	switch p {
+	case PlanDemo:
+		return "demo"
+	case PlanPro:
+		return "pro"
+	default:
+		return "public"
+	}
+}
+
+// ParsePlan converts "public", "demo" or "pro" into a Plan
+func ParsePlan(s string) (Plan, error) {
+	switch s {
+	case "public":
+		return PlanPublic, nil
+	case "demo":
+		return PlanDemo, nil
+	case "pro":
+		return PlanPro, nil
+	}
+	return PlanPublic, fmt.Errorf("unknown coingecko plan %q", s)
+}
+
+// RateLimit returns the request allowance of the plan, to be used with a rate
+// limiting decorator. Pro figures are those of the entry level paid plan.
+func (p Plan) RateLimit() (requests int, per time.Duration) {
+	switch p {
+	case PlanDemo:
+		return 30, time.Minute
+	case PlanPro:
+		return 500, time.Minute
+	default:
+		return 10, time.Minute
+	}
+}
+
+func (p Plan) baseURL() string {
+	if p == PlanPro {
+		return proBaseURL
+	}
+	return publicBaseURL
+}
+
+// keyHeader returns the header carrying the API key, or "" when none is sent
+func (p Plan) keyHeader() string {
+	switch p {
+	case PlanDemo:
+		return "x-cg-demo-api-key"
+	case PlanPro:
+		return "x-cg-pro-api-key"
+	}
+	return ""
+}
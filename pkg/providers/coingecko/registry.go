@@ -0,0 +1,370 @@
+package coingecko
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+// Coin is an entry of the CoinGecko catalog
+type Coin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	// Platforms maps a chain such as "ethereum" to the coin's contract address on it
+	Platforms map[string]string `json:"platforms,omitempty"`
+}
+
+// Disambiguation decides which coin a ticker or name shared by several coins
+// resolves to
+type Disambiguation int
+
+const (
+	// ByMarketCap picks the candidate with the largest market capitalisation
+	ByMarketCap Disambiguation = iota
+	// Strict fails with a *domain.AmbiguousSymbolError
+	Strict
+)
+
+const (
+	defaultCatalogTTL = 24 * time.Hour
+	// catalogRetry delays the next download after a failed one. A stale catalog
+	// keeps being used meanwhile; without one, lookups fail with the last error.
+	catalogRetry = time.Minute
+)
+
+// RegistryOption configures a Registry
+type RegistryOption func(*Registry)
+
+// WithCatalogCache persists the catalog to path so restarts do not download it again
+func WithCatalogCache(path string) RegistryOption {
+	return func(r *Registry) {
+		r.path = path
+	}
+}
+
+// WithCatalogTTL sets how long the catalog is used before it is refreshed.
+// Defaults to 24 hours.
+func WithCatalogTTL(ttl time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.ttl = ttl
+	}
+}
+
+// WithDisambiguation sets the policy for ambiguous tickers and names.
+// Defaults to ByMarketCap.
+func WithDisambiguation(d Disambiguation) RegistryOption {
+	return func(r *Registry) {
+		r.policy = d
+	}
+}
+
+// WithCatalogLimiter makes the registry call wait before each of its own requests, the
+// /coins/list download and the /coins/markets lookups used to disambiguate tickers.
+// These requests are made from inside the provider, so a rate limiting decorator
+// wrapping the provider does not see them; pass the Wait of the same limiter, for
+// example func(ctx context.Context) error { return limiter.Wait(ctx, 1) }, to count
+// them against the plan's allowance.
+func WithCatalogLimiter(wait func(ctx context.Context) error) RegistryOption {
+	return func(r *Registry) {
+		r.wait = wait
+	}
+}
+
+// WithAliases pins symbols to CoinGecko ids, bypassing the catalog lookup
+func WithAliases(aliases map[string]string) RegistryOption {
+	return func(r *Registry) {
+		for symbol, id := range aliases {
+			r.aliases[strings.ToLower(symbol)] = id
+		}
+	}
+}
+
+// Registry resolves tickers, names and contract addresses to CoinGecko ids using
+// the /coins/list catalog. Lookups are case-insensitive and tried in the order
+// alias, id, contract address, ticker, name.
+//
+// The registry downloads the catalog and market data itself, without going through
+// the provider's middleware, see WithCatalogLimiter.
+type Registry struct {
+	fetch   func(ctx context.Context, url string, v interface{}) error
+	wait    func(ctx context.Context) error
+	baseURL string
+
+	path    string
+	ttl     time.Duration
+	policy  Disambiguation
+	aliases map[string]string
+
+	mu          sync.Mutex
+	loading     chan struct{} // closed when the download in progress ends
+	loadErr     error         // last failed download while no catalog is loaded
+	fetchedAt   time.Time
+	nextRefresh time.Time
+	byID        map[string]*Coin
+	byContract  map[string]*Coin
+	bySymbol    map[string][]*Coin
+	byName      map[string][]*Coin
+	chosen      map[string]string // ambiguous key -> id picked by market cap
+}
+
+func newRegistry(fetch func(ctx context.Context, url string, v interface{}) error, baseURL string, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		fetch:   fetch,
+		baseURL: baseURL,
+		ttl:     defaultCatalogTTL,
+		aliases: make(map[string]string),
+		chosen:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns the CoinGecko id for symbol. Unknown symbols fail with
+// domain.ErrSymbolNotFound.
+func (r *Registry) Resolve(ctx context.Context, symbol string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(symbol))
+	if id, ok := r.aliases[key]; ok {
+		return id, nil
+	}
+
+	candidates, chosen, err := r.lookup(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case chosen != "":
+		return chosen, nil
+	case len(candidates) == 0:
+		return "", fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, symbol)
+	case len(candidates) == 1:
+		return candidates[0].ID, nil
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	sort.Strings(ids)
+	if r.policy == Strict {
+		return "", &domain.AmbiguousSymbolError{Symbol: symbol, Candidates: ids}
+	}
+
+	id, err := r.largestMarketCap(ctx, ids)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", &domain.AmbiguousSymbolError{Symbol: symbol, Candidates: ids}
+	}
+
+	r.mu.Lock()
+	r.chosen[key] = id
+	r.mu.Unlock()
+	return id, nil
+}
+
+// lookup returns the coins matching key, or the id previously chosen for it
+func (r *Registry) lookup(ctx context.Context, key string) ([]*Coin, string, error) {
+	if err := r.ensureLoaded(ctx); err != nil {
+		return nil, "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.byID[key]; ok {
+		return nil, c.ID, nil
+	}
+	if c, ok := r.byContract[key]; ok {
+		return nil, c.ID, nil
+	}
+	if id, ok := r.chosen[key]; ok {
+		return nil, id, nil
+	}
+	if coins := r.bySymbol[key]; len(coins) > 0 {
+		return coins, "", nil
+	}
+	return r.byName[key], "", nil
+}
+
+// ensureLoaded loads the catalog from disk or upstream and refreshes it once it
+// is older than the TTL. Concurrent callers share one download, made without r.mu
+// held. A stale catalog keeps being used while refreshes fail, and a failed first
+// download is not retried before catalogRetry.
+func (r *Registry) ensureLoaded(ctx context.Context) error {
+	r.mu.Lock()
+	if r.byID == nil && r.path != "" {
+		r.readCache()
+	}
+	for {
+		now := time.Now()
+		if r.byID != nil && (now.Sub(r.fetchedAt) < r.ttl || now.Before(r.nextRefresh) || r.loading != nil) {
+			r.mu.Unlock()
+			return nil
+		}
+		if r.byID == nil && now.Before(r.nextRefresh) {
+			err := r.loadErr
+			r.mu.Unlock()
+			return err
+		}
+		if r.loading == nil {
+			break
+		}
+
+		loading := r.loading
+		r.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		r.mu.Lock()
+	}
+	done := make(chan struct{})
+	r.loading = done
+	r.mu.Unlock()
+
+	var coins []Coin
+	err := r.get(ctx, r.baseURL+"/coins/list?include_platform=true", &coins)
+
+	r.mu.Lock()
+	r.loading = nil
+	close(done)
+	now := time.Now()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Only this caller gave up, a waiting caller downloads again
+		r.mu.Unlock()
+		return err
+	case err != nil:
+		r.nextRefresh = now.Add(catalogRetry)
+		if r.byID != nil {
+			r.mu.Unlock()
+			return nil
+		}
+		r.loadErr = fmt.Errorf("loading coin catalog: %w", err)
+		r.mu.Unlock()
+		return r.loadErr
+	}
+	r.index(coins, now)
+	r.loadErr = nil
+	r.mu.Unlock()
+
+	if r.path != "" {
+		// The catalog is still usable in memory when it cannot be persisted
+		_ = r.writeCache(coins, now)
+	}
+	return nil
+}
+
+// get makes a request of the registry, waiting for the catalog limiter first
+func (r *Registry) get(ctx context.Context, url string, v interface{}) error {
+	if r.wait != nil {
+		if err := r.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return r.fetch(ctx, url, v)
+}
+
+// catalogFile is the on-disk format of the catalog cache
+type catalogFile struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Coins     []Coin    `json:"coins"`
+}
+
+func (r *Registry) readCache() {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return
+	}
+	var f catalogFile
+	if json.Unmarshal(data, &f) != nil || len(f.Coins) == 0 {
+		return
+	}
+	r.index(f.Coins, f.FetchedAt)
+}
+
+// writeCache replaces the cache file atomically so concurrent readers never see
+// a partial catalog
+func (r *Registry) writeCache(coins []Coin, fetchedAt time.Time) error {
+	data, err := json.Marshal(catalogFile{FetchedAt: fetchedAt, Coins: coins})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// index rebuilds the lookup tables. Previous market cap choices are dropped as
+// the candidates may have changed.
+func (r *Registry) index(coins []Coin, fetchedAt time.Time) {
+	r.byID = make(map[string]*Coin, len(coins))
+	r.byContract = make(map[string]*Coin)
+	r.bySymbol = make(map[string][]*Coin, len(coins))
+	r.byName = make(map[string][]*Coin, len(coins))
+	r.chosen = make(map[string]string)
+
+	for i := range coins {
+		c := &coins[i]
+		r.byID[c.ID] = c
+		for _, addr := range c.Platforms {
+			if addr != "" {
+				r.byContract[strings.ToLower(addr)] = c
+			}
+		}
+		sym := strings.ToLower(c.Symbol)
+		r.bySymbol[sym] = append(r.bySymbol[sym], c)
+		name := strings.ToLower(c.Name)
+		r.byName[name] = append(r.byName[name], c)
+	}
+	r.fetchedAt = fetchedAt
+	r.nextRefresh = time.Time{}
+}
+
+// marketsResponse matches the fields used from /coins/markets
+type marketsResponse []struct {
+	ID        string         `json:"id"`
+	MarketCap domain.Decimal `json:"market_cap"` // zero when null
+}
+
+// largestMarketCap returns the id with the largest market capitalisation, or ""
+// when none of the candidates has market data
+func (r *Registry) largestMarketCap(ctx context.Context, ids []string) (string, error) {
+	query := url.Values{"vs_currency": {"usd"}, "ids": {strings.Join(ids, ",")}}
+	var data marketsResponse
+	if err := r.get(ctx, r.baseURL+"/coins/markets?"+query.Encode(), &data); err != nil {
+		return "", err
+	}
+
+	var best string
+	var bestCap domain.Decimal
+	for _, m := range data {
+		if m.MarketCap.Cmp(bestCap) > 0 {
+			best, bestCap = m.ID, m.MarketCap
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,185 @@
+package coingecko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+const catalogBody = `[
+	{"id":"bitcoin","symbol":"btc","name":"Bitcoin","platforms":{}},
+	{"id":"uniswap","symbol":"uni","name":"Uniswap","platforms":{"ethereum":"0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"}},
+	{"id":"universe","symbol":"uni","name":"Universe","platforms":{}}
+]`
+
+func newCatalogServer(t *testing.T, listCalls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/list":
+			listCalls.Add(1)
+			fmt.Fprint(w, catalogBody)
+		case "/coins/markets":
+			fmt.Fprint(w, `[{"id":"uniswap","market_cap":5000000000},{"id":"universe","market_cap":null}]`)
+		case "/simple/price":
+			fmt.Fprint(w, `{"bitcoin":{"usd":50000},"uniswap":{"usd":7}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRegistryResolve(t *testing.T) {
+	var calls atomic.Int32
+	srv := newCatalogServer(t, &calls)
+	ctx := context.Background()
+
+	p := NewProvider(WithBaseURL(srv.URL), WithRegistry(WithAliases(map[string]string{"xbt": "bitcoin"})))
+	for symbol, want := range map[string]string{
+		"bitcoin": "bitcoin", // id
+		"BTC":     "bitcoin", // ticker
+		"Bitcoin": "bitcoin", // name
+		"XBT":     "bitcoin", // alias
+		"0x1F9840A85D5AF5BF1D1762F925BDADDC4201F984": "uniswap", // contract
+		"UNI": "uniswap", // ambiguous ticker, largest market cap
+	} {
+		got, err := p.Registry().Resolve(ctx, symbol)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", symbol, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", symbol, want, got)
+		}
+	}
+	if _, err := p.Registry().Resolve(ctx, "nope"); !errors.Is(err, domain.ErrSymbolNotFound) {
+		t.Errorf("expected ErrSymbolNotFound, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the catalog to be loaded once, got %d", n)
+	}
+
+	// Quotes echo the requested and the canonical symbol
+	quotes, err := p.GetQuotes(ctx, []string{"BTC", "bitcoin", "uni"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := quotes["BTC"]; q == nil || q.Symbol != "BTC" || q.CanonicalSymbol != "bitcoin" || !q.Price.Equal(domain.NewDecimalFromInt(50000)) {
+		t.Errorf("unexpected BTC quote: %+v", q)
+	}
+	if q := quotes["uni"]; q == nil || q.CanonicalSymbol != "uniswap" {
+		t.Errorf("unexpected uni quote: %+v", q)
+	}
+}
+
+func TestRegistryStrict(t *testing.T) {
+	var calls atomic.Int32
+	srv := newCatalogServer(t, &calls)
+
+	p := NewProvider(WithBaseURL(srv.URL), WithRegistry(WithDisambiguation(Strict)))
+	_, err := p.GetQuote(context.Background(), "UNI")
+	var ambiguous *domain.AmbiguousSymbolError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("expected AmbiguousSymbolError with 2 candidates, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrSymbolNotFound) {
+		t.Errorf("ambiguous symbols should match ErrSymbolNotFound")
+	}
+}
+
+func TestRegistryCatalogCache(t *testing.T) {
+	var calls atomic.Int32
+	srv := newCatalogServer(t, &calls)
+	path := filepath.Join(t.TempDir(), "coins.json")
+	ctx := context.Background()
+
+	first := NewProvider(WithBaseURL(srv.URL), WithRegistry(WithCatalogCache(path)))
+	if _, err := first.Registry().Resolve(ctx, "BTC"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new registry reads the cached catalog instead of downloading it
+	second := NewProvider(WithBaseURL(srv.URL), WithRegistry(WithCatalogCache(path)))
+	if id, err := second.Registry().Resolve(ctx, "BTC"); err != nil || id != "bitcoin" {
+		t.Fatalf("expected bitcoin from cache, got %q, %v", id, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 catalog download, got %d", n)
+	}
+}
+
+func TestRegistrySharedDownload(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		fmt.Fprint(w, catalogBody)
+	}))
+	t.Cleanup(srv.Close)
+
+	var waits atomic.Int32
+	p := NewProvider(WithBaseURL(srv.URL), WithRegistry(WithCatalogLimiter(func(ctx context.Context) error {
+		waits.Add(1)
+		return nil
+	})))
+
+	// Concurrent lookups wait for a single download
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := p.Registry().Resolve(context.Background(), "BTC"); err != nil || id != "bitcoin" {
+				t.Errorf("expected bitcoin, got %q, %v", id, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 catalog download, got %d", n)
+	}
+	if n := waits.Load(); n != 1 {
+		t.Errorf("expected the download to wait for the limiter once, got %d", n)
+	}
+}
+
+func TestRegistryColdFailureBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewProvider(WithBaseURL(srv.URL), WithRegistry())
+	symbols := make([]string, 300)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("coin%d", i)
+	}
+
+	// Every symbol of the batch fails with the cached error instead of downloading again
+	if _, err := p.GetQuotes(context.Background(), symbols); err == nil {
+		t.Fatal("expected an error without a catalog")
+	}
+	if _, err := p.Registry().Resolve(context.Background(), "BTC"); err == nil {
+		t.Error("expected the catalog failure to be returned")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 catalog download, got %d", n)
+	}
+}
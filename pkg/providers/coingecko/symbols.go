@@ -0,0 +1,23 @@
+package coingecko
+
+import (
+	"fmt"
+
+	"markets-sdk/pkg/domain"
+)
+
+// NativeSymbol translates a crypto instrument into the symbol passed to GetQuote.
+// Tickers are only meaningful with WithRegistry; without it the instrument needs a
+// CoinGecko id in Instrument.Codes. Prices are quoted in USD.
+func (p *Provider) NativeSymbol(inst domain.Instrument) (string, error) {
+	if inst.AssetType != domain.AssetTypeCrypto {
+		return "", fmt.Errorf("%w: asset type %s", domain.ErrNotSupported, inst.AssetType)
+	}
+	if inst.Quote != "" && inst.Quote != "USD" {
+		return "", fmt.Errorf("%w: quote currency %s", domain.ErrNotSupported, inst.Quote)
+	}
+	if p.registry == nil {
+		return "", fmt.Errorf("%w: resolving ticker %s requires WithRegistry", domain.ErrNotSupported, inst.Base)
+	}
+	return inst.Base, nil
+}
@@ -0,0 +1,180 @@
+// Package httpx holds the HTTP helpers shared by the built-in providers
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// DefaultTimeout is the request timeout of the built-in providers
+const DefaultTimeout = 10 * time.Second
+
+// DefaultTransport is shared by the built-in providers so they reuse connections.
+// Market data calls are small and frequent, so more idle connections are kept per
+// host than net/http's default of 2.
+var DefaultTransport http.RoundTripper = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   32,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
+// ClientConfig collects the HTTP settings exposed as provider options
+type ClientConfig struct {
+	Client    *http.Client
+	Transport http.RoundTripper
+	Timeout   time.Duration
+}
+
+// Build returns the client to use. A caller supplied client is copied, never
+// modified, before the transport and timeout overrides are applied.
+func (c ClientConfig) Build() *http.Client {
+	client := &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: DefaultTransport,
+	}
+	if c.Client != nil {
+		cp := *c.Client
+		client = &cp
+	}
+	if c.Transport != nil {
+		client.Transport = c.Transport
+	}
+	if c.Timeout > 0 {
+		client.Timeout = c.Timeout
+	}
+	return client
+}
+
+// maxBodySnippet bounds how much of an error response body is kept in *domain.HTTPError
+const maxBodySnippet = 256
+
+// CheckResponse returns nil for 2xx responses and a typed error otherwise.
+// 429 responses are reported as *domain.RateLimitError wrapping the *domain.HTTPError.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+	httpErr := &domain.HTTPError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(snippet)),
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &domain.RateLimitError{
+			RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Err:        httpErr,
+		}
+	}
+	return httpErr
+}
+
+// ObserveRateLimit forwards the X-RateLimit-* and Retry-After headers of resp to the
+// rate limit observers in ctx. Nothing is reported when the upstream sent no signal.
+func ObserveRateLimit(ctx context.Context, resp *http.Response) {
+	info, ok := ParseRateLimit(resp, time.Now())
+	if ok {
+		ports.ReportRateLimit(ctx, info)
+	}
+}
+
+// ParseRateLimit extracts the rate limit signals of resp. X-RateLimit-Reset is accepted
+// both as a unix timestamp and as a number of seconds.
+func ParseRateLimit(resp *http.Response, now time.Time) (domain.RateLimitInfo, bool) {
+	h := resp.Header
+	info := domain.RateLimitInfo{
+		StatusCode: resp.StatusCode,
+		Limit:      headerInt(h, "X-RateLimit-Limit"),
+		Remaining:  headerInt(h, "X-RateLimit-Remaining"),
+		RetryAfter: ParseRetryAfter(h.Get("Retry-After"), now),
+	}
+
+	if reset := headerInt(h, "X-RateLimit-Reset"); reset > 0 {
+		// Values this large can only be timestamps
+		if reset > 1_000_000_000 {
+			if d := time.Unix(int64(reset), 0).Sub(now); d > 0 {
+				info.Reset = d
+			}
+		} else {
+			info.Reset = time.Duration(reset) * time.Second
+		}
+	}
+
+	ok := resp.StatusCode == http.StatusTooManyRequests || info.Limit >= 0 || info.Remaining >= 0 || info.RetryAfter > 0
+	return info, ok
+}
+
+func headerInt(h http.Header, key string) int {
+	v, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+// WrapTransportError marks failures to reach the upstream as domain.ErrUpstreamUnavailable,
+// leaving cancellation of the caller's context untouched
+func WrapTransportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", domain.ErrUpstreamUnavailable, err)
+}
+
+// ParseRetryAfter understands both the delay-seconds and HTTP-date forms of Retry-After
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Numbers converts upstream JSON numbers to decimals without going through float64.
+// Like bufio.Scanner it records the first malformed number in Err, so a response
+// can be converted field by field and checked once.
+type Numbers struct {
+	Err error
+}
+
+// Decimal parses v, returning zero for a missing (empty) number
+func (n *Numbers) Decimal(v json.Number) domain.Decimal {
+	if v == "" {
+		return domain.Decimal{}
+	}
+	d, err := domain.ParseDecimal(string(v))
+	if err != nil && n.Err == nil {
+		n.Err = fmt.Errorf("malformed number in response: %w", err)
+	}
+	return d
+}
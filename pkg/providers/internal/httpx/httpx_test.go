@@ -0,0 +1,118 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+func response(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	if err := CheckResponse(response(http.StatusOK, nil, "")); err != nil {
+		t.Errorf("unexpected error for 200: %v", err)
+	}
+
+	// 1. 404 maps onto ErrSymbolNotFound and keeps the body snippet
+	err := CheckResponse(response(http.StatusNotFound, nil, strings.Repeat("x", 1000)))
+	if !errors.Is(err, domain.ErrSymbolNotFound) {
+		t.Errorf("expected ErrSymbolNotFound, got %v", err)
+	}
+	var httpErr *domain.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 404 || len(httpErr.Body) != maxBodySnippet {
+		t.Errorf("unexpected HTTPError: %+v", httpErr)
+	}
+
+	// 2. 429 carries Retry-After
+	err = CheckResponse(response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"3"}}, ""))
+	var rlErr *domain.RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.RetryAfter != 3*time.Second {
+		t.Errorf("expected RateLimitError with 3s retry after, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrRateLimited) || !errors.As(err, &httpErr) {
+		t.Errorf("expected rate limit error to match ErrRateLimited and wrap HTTPError, got %v", err)
+	}
+
+	// 3. 5xx is an unavailable upstream
+	err = CheckResponse(response(http.StatusBadGateway, nil, ""))
+	if !errors.Is(err, domain.ErrUpstreamUnavailable) {
+		t.Errorf("expected ErrUpstreamUnavailable, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if d := ParseRetryAfter("120", now); d != 2*time.Minute {
+		t.Errorf("expected 2m, got %v", d)
+	}
+	if d := ParseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now); d != 30*time.Second {
+		t.Errorf("expected 30s, got %v", d)
+	}
+	if d := ParseRetryAfter("soon", now); d != 0 {
+		t.Errorf("expected 0 for invalid value, got %v", d)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "30")
+	h.Set("X-RateLimit-Remaining", "4")
+	h.Set("X-RateLimit-Reset", "1704067260") // now + 60s
+	info, ok := ParseRateLimit(response(http.StatusOK, h, ""), now)
+	if !ok {
+		t.Fatal("expected rate limit info")
+	}
+	if info.Limit != 30 || info.Remaining != 4 || info.Reset != time.Minute {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	// Relative reset
+	h.Set("X-RateLimit-Reset", "15")
+	info, _ = ParseRateLimit(response(http.StatusOK, h, ""), now)
+	if info.Reset != 15*time.Second {
+		t.Errorf("expected 15s reset, got %v", info.Reset)
+	}
+
+	// No signal at all
+	if _, ok := ParseRateLimit(response(http.StatusOK, nil, ""), now); ok {
+		t.Error("expected no rate limit info without headers")
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	var nums Numbers
+	if d := nums.Decimal("64230.123456789012345"); d.String() != "64230.123456789012345" {
+		t.Errorf("expected every digit kept, got %s", d)
+	}
+	if d := nums.Decimal(""); !d.IsZero() {
+		t.Errorf("expected zero for a missing number, got %s", d)
+	}
+	if nums.Err != nil {
+		t.Fatalf("unexpected error: %v", nums.Err)
+	}
+
+	// The first malformed number is recorded
+	nums.Decimal(json.Number("1.2.3"))
+	nums.Decimal(json.Number("x"))
+	if nums.Err == nil || !strings.Contains(nums.Err.Error(), "1.2.3") {
+		t.Errorf("expected error for 1.2.3, got %v", nums.Err)
+	}
+}
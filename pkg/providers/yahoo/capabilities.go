@@ -0,0 +1,36 @@
+package yahoo
+
+import (
+	"sort"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Capabilities reports batch quotes, history for the chart intervals, and stocks on
+// the exchanges NativeSymbol can translate as well as crypto pairs. GetQuote reads
+// the chart metadata, which has no volume; GetQuotes does populate it.
+func (p *Provider) Capabilities() ports.Capabilities {
+	intervals := make([]domain.Interval, 0, len(chartIntervals))
+	for i := range chartIntervals {
+		intervals = append(intervals, i)
+	}
+	sort.Slice(intervals, func(a, b int) bool { return intervals[a].Duration() < intervals[b].Duration() })
+
+	exchanges := make([]string, 0, len(exchangeSuffixes))
+	for mic := range exchangeSuffixes {
+		exchanges = append(exchanges, mic)
+	}
+	sort.Strings(exchanges)
+
+	return ports.Capabilities{
+		Batch:        true,
+		MaxBatchSize: maxBatchSize,
+		History:      true,
+		Intervals:    intervals,
+		Coverage: domain.Coverage{
+			AssetTypes: []domain.AssetType{domain.AssetTypeStock, domain.AssetTypeCrypto},
+			Exchanges:  exchanges,
+		},
+	}
+}
@@ -5,61 +5,322 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/providers/internal/httpx"
 )
 
-const baseURL = "https://query2.finance.yahoo.com/v8/finance/chart"
+// baseURL is the host used unless WithBaseURL is given
+const baseURL = "https://query2.finance.yahoo.com"
+
+const (
+	chartPath = "/v8/finance/chart"
+	quotePath = "/v7/finance/quote"
+)
+
+// maxBatchSize bounds the number of symbols sent in a single quote request
+const maxBatchSize = 100
+
+// defaultUserAgent is sent unless WithUserAgent is given; Yahoo Finance often blocks
+// requests without a browser User-Agent
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.114 Safari/537.36"
 
 type Provider struct {
-	client *http.Client
+	client    *http.Client
+	baseURL   string
+	userAgent string
 }
 
-func NewProvider() *Provider {
+func NewProvider(opts ...Option) *Provider {
+	o := options{baseURL: baseURL, userAgent: defaultUserAgent}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return &Provider{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:    httpx.ClientConfig{Client: o.client, Transport: o.transport, Timeout: o.timeout}.Build(),
+		baseURL:   o.baseURL,
+		userAgent: o.userAgent,
 	}
 }
 
+// chartResult is a single entry of the chart API result array
+type chartResult struct {
+	Meta struct {
+		Symbol             string      `json:"symbol"`
+		RegularMarketPrice json.Number `json:"regularMarketPrice"`
+		RegularMarketTime  int64       `json:"regularMarketTime"`
+		PreviousClose      json.Number `json:"chartPreviousClose"`
+	} `json:"meta"`
+	Timestamp  []int64 `json:"timestamp"`
+	Indicators struct {
+		// Values are nullable: Yahoo emits null for intervals without trades
+		Quote []struct {
+			Open   []*json.Number `json:"open"`
+			High   []*json.Number `json:"high"`
+			Low    []*json.Number `json:"low"`
+			Close  []*json.Number `json:"close"`
+			Volume []*json.Number `json:"volume"`
+		} `json:"quote"`
+	} `json:"indicators"`
+}
+
 // chartResponse matches the structure of Yahoo Finance chart API
 type chartResponse struct {
 	Chart struct {
+		Result []chartResult `json:"result"`
+		Error  *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// quoteResponse matches the structure of the multi-symbol quote API
+type quoteResponse struct {
+	QuoteResponse struct {
 		Result []struct {
-			Meta struct {
-				Symbol             string  `json:"symbol"`
-				RegularMarketPrice float64 `json:"regularMarketPrice"`
-				RegularMarketTime  int64   `json:"regularMarketTime"`
-				PreviousClose      float64 `json:"chartPreviousClose"`
-			} `json:"meta"`
+			Symbol              string      `json:"symbol"`
+			RegularMarketPrice  json.Number `json:"regularMarketPrice"`
+			RegularMarketChange json.Number `json:"regularMarketChange"`
+			RegularMarketVolume json.Number `json:"regularMarketVolume"`
+			RegularMarketTime   int64       `json:"regularMarketTime"`
 		} `json:"result"`
 		Error *struct {
 			Code        string `json:"code"`
 			Description string `json:"description"`
 		} `json:"error"`
-	} `json:"chart"`
+	} `json:"quoteResponse"`
+}
+
+// chartIntervals maps domain intervals to the values accepted by the chart API
+var chartIntervals = map[domain.Interval]string{
+	domain.Interval1m:  "1m",
+	domain.Interval5m:  "5m",
+	domain.Interval15m: "15m",
+	domain.Interval30m: "30m",
+	domain.Interval1h:  "60m",
+	domain.Interval1d:  "1d",
+	domain.Interval1w:  "1wk",
 }
 
 func (p *Provider) GetQuote(ctx context.Context, symbol string) (*domain.Quote, error) {
-	url := fmt.Sprintf("%s/%s?interval=1m&range=1d", baseURL, symbol)
+	endpoint := fmt.Sprintf("%s%s/%s?interval=1m&range=1d", p.baseURL, chartPath, url.PathEscape(symbol))
+
+	result, err := p.fetchChart(ctx, endpoint, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := result.Meta
+
+	var nums httpx.Numbers
+	price := nums.Decimal(meta.RegularMarketPrice)
+	// Calculate simple change
+	change := price.Sub(nums.Decimal(meta.PreviousClose))
+	if nums.Err != nil {
+		return nil, nums.Err
+	}
+
+	// Note: Volume is in the 'indicators' part of the JSON which is more complex to parse for just a quote.
+	// For this MVP, we will omit volume or could parse it if strictly needed.
+	// Let's stick to price and change for now.
+
+	return &domain.Quote{
+		Symbol:      meta.Symbol,
+		Price:       price,
+		Change24h:   change,
+		LastUpdated: time.Unix(meta.RegularMarketTime, 0),
+		Source:      "yahoo",
+	}, nil
+}
+
+// GetQuotes fetches several quotes through the multi-symbol quote endpoint.
+// Large batches are split into requests of at most maxBatchSize symbols.
+func (p *Provider) GetQuotes(ctx context.Context, symbols []string) (map[string]*domain.Quote, error) {
+	quotes := make(map[string]*domain.Quote, len(symbols))
+	failed := make(map[string]error)
+
+	// Commas separate the symbols of a request, so a symbol containing one cannot be sent
+	valid := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if strings.Contains(s, ",") {
+			failed[s] = fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, s)
+			continue
+		}
+		valid = append(valid, s)
+	}
+	symbols = valid
+
+	for start := 0; start < len(symbols); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		chunk := symbols[start:end]
+
+		data, err := p.fetchQuotes(ctx, chunk)
+		if err != nil {
+			for _, s := range chunk {
+				failed[s] = err
+			}
+			continue
+		}
+
+		// Yahoo echoes symbols upper-cased, so match case-insensitively
+		bySymbol := make(map[string]int, len(data.QuoteResponse.Result))
+		for i, r := range data.QuoteResponse.Result {
+			bySymbol[strings.ToUpper(r.Symbol)] = i
+		}
+
+		for _, s := range chunk {
+			i, ok := bySymbol[strings.ToUpper(s)]
+			if !ok {
+				failed[s] = fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, s)
+				continue
+			}
+			r := data.QuoteResponse.Result[i]
+			var nums httpx.Numbers
+			q := &domain.Quote{
+				Symbol:      r.Symbol,
+				Price:       nums.Decimal(r.RegularMarketPrice),
+				Change24h:   nums.Decimal(r.RegularMarketChange),
+				Volume:      nums.Decimal(r.RegularMarketVolume),
+				LastUpdated: time.Unix(r.RegularMarketTime, 0),
+				Source:      "yahoo",
+			}
+			if nums.Err != nil {
+				failed[s] = nums.Err
+				continue
+			}
+			quotes[s] = q
+		}
+	}
+
+	if len(failed) > 0 {
+		return quotes, &domain.BatchError{Errors: failed}
+	}
+	return quotes, nil
+}
+
+// fetchQuotes calls the quote API for a set of symbols
+func (p *Provider) fetchQuotes(ctx context.Context, symbols []string) (*quoteResponse, error) {
+	query := url.Values{"symbols": {strings.Join(symbols, ",")}}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+quotePath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, httpx.WrapTransportError(ctx, err)
+	}
+	defer resp.Body.Close()
+	httpx.ObserveRateLimit(ctx, resp)
+
+	if err := httpx.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var data quoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if data.QuoteResponse.Error != nil {
+		return nil, fmt.Errorf("yahoo api error: %s - %s", data.QuoteResponse.Error.Code, data.QuoteResponse.Error.Description)
+	}
+	return &data, nil
+}
+
+// GetHistory returns OHLCV candles from the chart endpoint for the given range.
+// Intervals without any trades are skipped.
+func (p *Provider) GetHistory(ctx context.Context, symbol string, interval domain.Interval, from, to time.Time) ([]domain.Candle, error) {
+	yi, ok := chartIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: interval %s", domain.ErrNotSupported, interval)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("invalid time range: %s - %s", from, to)
+	}
+
+	query := url.Values{
+		"interval": {yi},
+		"period1":  {strconv.FormatInt(from.Unix(), 10)},
+		"period2":  {strconv.FormatInt(to.Unix(), 10)},
+	}
+	endpoint := fmt.Sprintf("%s%s/%s?%s", p.baseURL, chartPath, url.PathEscape(symbol), query.Encode())
+
+	result, err := p.fetchChart(ctx, endpoint, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Indicators.Quote) == 0 {
+		return nil, nil
+	}
+
+	q := result.Indicators.Quote[0]
+	candles := make([]domain.Candle, 0, len(result.Timestamp))
+	var nums httpx.Numbers
+	for i, ts := range result.Timestamp {
+		open, high, low, cls := at(q.Open, i), at(q.High, i), at(q.Low, i), at(q.Close, i)
+		if open == nil || high == nil || low == nil || cls == nil {
+			continue
+		}
+
+		var volume domain.Decimal
+		if v := at(q.Volume, i); v != nil {
+			volume = nums.Decimal(*v)
+		}
+
+		candles = append(candles, domain.Candle{
+			Symbol:   result.Meta.Symbol,
+			Interval: interval,
+			Start:    time.Unix(ts, 0),
+			Open:     nums.Decimal(*open),
+			High:     nums.Decimal(*high),
+			Low:      nums.Decimal(*low),
+			Close:    nums.Decimal(*cls),
+			Volume:   volume,
+			Source:   "yahoo",
+		})
+	}
+	if nums.Err != nil {
+		return nil, nums.Err
+	}
+	return candles, nil
+}
 
+// at returns values[i] or nil when the index is out of range
+func at(values []*json.Number, i int) *json.Number {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
+
+// fetchChart calls the chart API and returns the first result
+func (p *Provider) fetchChart(ctx context.Context, url string, symbol string) (*chartResult, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	// Yahoo Finance often requires a User-Agent to not block the request
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.114 Safari/537.36")
+	req.Header.Set("User-Agent", p.userAgent)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, httpx.WrapTransportError(ctx, err)
 	}
 	defer resp.Body.Close()
+	httpx.ObserveRateLimit(ctx, resp)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if err := httpx.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	var data chartResponse
@@ -68,28 +329,15 @@ func (p *Provider) GetQuote(ctx context.Context, symbol string) (*domain.Quote,
 	}
 
 	if data.Chart.Error != nil {
+		if data.Chart.Error.Code == "Not Found" {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, symbol)
+		}
 		return nil, fmt.Errorf("yahoo api error: %s - %s", data.Chart.Error.Code, data.Chart.Error.Description)
 	}
 
 	if len(data.Chart.Result) == 0 {
-		return nil, fmt.Errorf("symbol %s not found", symbol)
+		return nil, fmt.Errorf("%w: %s", domain.ErrSymbolNotFound, symbol)
 	}
 
-	meta := data.Chart.Result[0].Meta
-
-	// Calculate simple change
-	change := meta.RegularMarketPrice - meta.PreviousClose
-
-	// Note: Volume is in the 'indicators' part of the JSON which is more complex to parse for just a quote.
-	// For this MVP, we will omit volume or could parse it if strictly needed.
-	// Let's stick to price and change for now.
-
-	return &domain.Quote{
-		Symbol:      meta.Symbol,
-		Price:       meta.RegularMarketPrice,
-		Change24h:   change,
-		Volume:      0, // Not easily available in Meta, needs parsing Quote array
-		LastUpdated: time.Unix(meta.RegularMarketTime, 0),
-		Source:      "yahoo",
-	}, nil
+	return &data.Chart.Result[0], nil
 }
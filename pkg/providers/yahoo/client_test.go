@@ -0,0 +1,157 @@
+package yahoo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"markets-sdk/pkg/domain"
+)
+
+const chartBody = `{"chart":{"result":[{
+	"meta":{"symbol":"AAPL","regularMarketPrice":190,"regularMarketTime":1700000000,"chartPreviousClose":185},
+	"timestamp":[1700000000,1700003600,1700007200],
+	"indicators":{"quote":[{
+		"open":[180,null,188],"high":[186,null,191],"low":[179,null,187],"close":[185,null,190],"volume":[1000,null,null]
+	}]}
+}],"error":null}}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("request sent without User-Agent")
+		}
+		switch r.URL.Path {
+		case chartPath + "/AAPL":
+			fmt.Fprint(w, chartBody)
+		case chartPath + "/NOPE":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found"}}}`)
+		case chartPath + "/BUSY":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case quotePath:
+			fmt.Fprint(w, `{"quoteResponse":{"result":[
+				{"symbol":"AAPL","regularMarketPrice":190,"regularMarketChange":5,"regularMarketVolume":1000,"regularMarketTime":1700000000}
+			],"error":null}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetQuote(t *testing.T) {
+	p := NewProvider(WithBaseURL(newTestServer(t).URL))
+	ctx := context.Background()
+
+	q, err := p.GetQuote(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Symbol != "AAPL" || !q.Price.Equal(domain.NewDecimalFromInt(190)) || !q.Change24h.Equal(domain.NewDecimalFromInt(5)) {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+
+	if _, err := p.GetQuote(ctx, "NOPE"); !errors.Is(err, domain.ErrSymbolNotFound) {
+		t.Errorf("expected ErrSymbolNotFound, got %v", err)
+	}
+	if _, err := p.GetQuote(ctx, "BUSY"); !errors.Is(err, domain.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	p := NewProvider(WithBaseURL(newTestServer(t).URL))
+
+	quotes, err := p.GetQuotes(context.Background(), []string{"aapl", "MISSING"})
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["MISSING"], domain.ErrSymbolNotFound) {
+		t.Fatalf("expected not found for MISSING, got %v", err)
+	}
+	if q := quotes["aapl"]; q == nil || !q.Price.Equal(domain.NewDecimalFromInt(190)) || !q.Volume.Equal(domain.NewDecimalFromInt(1000)) {
+		t.Errorf("unexpected quotes: %v", quotes)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	p := NewProvider(WithBaseURL(newTestServer(t).URL), WithTimeout(time.Second))
+	to := time.Unix(1700010000, 0)
+
+	candles, err := p.GetHistory(context.Background(), "AAPL", domain.Interval1h, to.Add(-3*time.Hour), to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The middle interval has no trades and is skipped
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(candles))
+	}
+	if c := candles[0]; !c.Open.Equal(domain.NewDecimalFromInt(180)) || !c.High.Equal(domain.NewDecimalFromInt(186)) || !c.Low.Equal(domain.NewDecimalFromInt(179)) || !c.Close.Equal(domain.NewDecimalFromInt(185)) || !c.Volume.Equal(domain.NewDecimalFromInt(1000)) {
+		t.Errorf("unexpected first candle: %+v", c)
+	}
+	if c := candles[1]; !c.Close.Equal(domain.NewDecimalFromInt(190)) || !c.Volume.IsZero() {
+		t.Errorf("unexpected second candle: %+v", c)
+	}
+
+	if _, err := p.GetHistory(context.Background(), "AAPL", domain.Interval4h, to.Add(-time.Hour), to); !errors.Is(err, domain.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported for 4h, got %v", err)
+	}
+}
+
+func TestNativeSymbol(t *testing.T) {
+	p := NewProvider()
+	for in, want := range map[string]string{
+		"stock:AAPL":      "AAPL",
+		"stock:XNAS:AAPL": "AAPL",
+		"stock:XLON:VOD":  "VOD.L",
+		"stock:BRK.B":     "BRK-B",
+		"crypto:BTC":      "BTC-USD",
+		"crypto:ETH/EUR":  "ETH-EUR",
+	} {
+		got, err := p.NativeSymbol(domain.MustParseInstrument(in))
+		if err != nil || got != want {
+			t.Errorf("%s: expected %s, got %q, %v", in, want, got, err)
+		}
+	}
+
+	if _, err := p.NativeSymbol(domain.MustParseInstrument("stock:XXXX:AAPL")); !errors.Is(err, domain.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported for unknown exchange, got %v", err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	c := NewProvider().Capabilities()
+	if !c.Batch || !c.History || c.Volume {
+		t.Errorf("unexpected capabilities: %+v", c)
+	}
+	if len(c.Intervals) != len(chartIntervals) || c.Intervals[0] != domain.Interval1m {
+		t.Errorf("expected sorted chart intervals, got %v", c.Intervals)
+	}
+	if !c.Coverage.Covers(domain.MustParseInstrument("stock:XLON:VOD")) || c.Coverage.Covers(domain.MustParseInstrument("stock:XXXX:VOD")) {
+		t.Errorf("unexpected coverage: %+v", c.Coverage)
+	}
+}
+
+func TestGetQuotesEscapesSymbols(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("symbols")
+		fmt.Fprint(w, `{"quoteResponse":{"result":[],"error":null}}`)
+	}))
+	defer srv.Close()
+
+	p := NewProvider(WithBaseURL(srv.URL))
+	_, err := p.GetQuotes(context.Background(), []string{"M&M.NS", "AAPL", "A,B"})
+	if got != "M&M.NS,AAPL" {
+		t.Errorf("expected the symbols to stay one query value, got %q", got)
+	}
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["A,B"], domain.ErrSymbolNotFound) {
+		t.Errorf("expected a symbol containing a comma to be rejected, got %v", err)
+	}
+}
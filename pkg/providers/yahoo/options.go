@@ -0,0 +1,56 @@
+package yahoo
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Provider
+type Option func(*options)
+
+type options struct {
+	client    *http.Client
+	transport http.RoundTripper
+	timeout   time.Duration
+	baseURL   string
+	userAgent string
+}
+
+// WithHTTPClient uses client for every request. The client is copied, so later
+// WithTimeout or WithTransport options do not modify it.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTransport replaces the HTTP transport, e.g. to route through a proxy
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithBaseURL points the provider at another host, such as a caching proxy or an
+// httptest.Server. Defaults to https://query2.finance.yahoo.com.
+func WithBaseURL(url string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request. Defaults to a
+// desktop browser, which Yahoo Finance requires.
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
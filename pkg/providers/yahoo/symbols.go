@@ -0,0 +1,56 @@
+package yahoo
+
+import (
+	"fmt"
+	"strings"
+
+	"markets-sdk/pkg/domain"
+)
+
+// exchangeSuffixes maps market identifier codes to the suffix Yahoo appends to
+// tickers listed there. US venues have no suffix.
+var exchangeSuffixes = map[string]string{
+	"XNAS": "",
+	"XNYS": "",
+	"ARCX": "",
+	"BATS": "",
+	"XASE": "",
+	"XLON": ".L",
+	"XETR": ".DE",
+	"XFRA": ".F",
+	"XPAR": ".PA",
+	"XAMS": ".AS",
+	"XBRU": ".BR",
+	"XMIL": ".MI",
+	"XMAD": ".MC",
+	"XSWX": ".SW",
+	"XSTO": ".ST",
+	"XTSE": ".TO",
+	"XTKS": ".T",
+	"XHKG": ".HK",
+	"XASX": ".AX",
+	"XNSE": ".NS",
+}
+
+// NativeSymbol translates an instrument into a Yahoo symbol: stocks become the
+// ticker with the exchange suffix (stock:XLON:VOD -> VOD.L) and crypto pairs become
+// BASE-QUOTE (crypto:BTC/EUR -> BTC-EUR, USD by default). Stocks are quoted in
+// their listing currency.
+func (p *Provider) NativeSymbol(inst domain.Instrument) (string, error) {
+	switch inst.AssetType {
+	case domain.AssetTypeStock:
+		suffix, ok := exchangeSuffixes[inst.Exchange]
+		if inst.Exchange != "" && !ok {
+			return "", fmt.Errorf("%w: exchange %s", domain.ErrNotSupported, inst.Exchange)
+		}
+		// Share classes use a dash: BRK.B is BRK-B
+		return strings.ReplaceAll(inst.Base, ".", "-") + suffix, nil
+	case domain.AssetTypeCrypto:
+		quote := inst.Quote
+		if quote == "" {
+			quote = "USD"
+		}
+		return inst.Base + "-" + quote, nil
+	}
+	return "", fmt.Errorf("%w: asset type %s", domain.ErrNotSupported, inst.AssetType)
+}
@@ -0,0 +1,112 @@
+package markets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// Route sends instruments matching AssetType and Exchange to Provider. Empty fields
+// match anything. Among matching routes the highest Priority wins; providers whose
+// ports.Capabilities cover the instrument take part with priority 0.
+type Route struct {
+	AssetType domain.AssetType
+	Exchange  string
+	Provider  string
+	Priority  int
+}
+
+// matches reports whether the route applies to inst
+func (r Route) matches(inst domain.Instrument) bool {
+	return (r.AssetType == "" || r.AssetType == inst.AssetType) &&
+		(r.Exchange == "" || r.Exchange == inst.Exchange)
+}
+
+// specificity ranks routes of equal priority: an exchange beats an asset type,
+// which beats a catch-all
+func (r Route) specificity() int {
+	n := 0
+	if r.Exchange != "" {
+		n += 2
+	}
+	if r.AssetType != "" {
+		n++
+	}
+	return n
+}
+
+// WithRoutes adds routing rules used by Quote and Resolve
+func WithRoutes(routes ...Route) ClientOption {
+	return func(c *MarketClient) {
+		c.routes = append(c.routes, routes...)
+	}
+}
+
+// AddRoute adds a routing rule used by Quote and Resolve
+func (c *MarketClient) AddRoute(r Route) {
+	c.routes = append(c.routes, r)
+}
+
+// Quote fetches a quote for inst from the provider chosen by Resolve. Use GetQuote
+// to pick a provider explicitly.
+func (c *MarketClient) Quote(ctx context.Context, inst domain.Instrument) (*domain.Quote, error) {
+	name, symbol, err := c.Resolve(inst)
+	if err != nil {
+		return nil, err
+	}
+	return c.providers[name].GetQuote(ctx, symbol)
+}
+
+// Resolve returns the provider that serves inst and its native symbol there.
+// Candidates come from the routes and from provider coverage, ordered by priority,
+// then specificity, then registration order. Candidates that cannot translate the
+// instrument are skipped; errors from the chosen provider are not retried elsewhere,
+// use decorators.NewFailover for that.
+func (c *MarketClient) Resolve(inst domain.Instrument) (provider, symbol string, err error) {
+	for _, r := range c.candidates(inst) {
+		symbol, err := c.NativeSymbol(r.Provider, inst)
+		if errors.Is(err, domain.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return r.Provider, symbol, nil
+	}
+	return "", "", fmt.Errorf("%w: no provider routes %s", domain.ErrProviderNotFound, inst)
+}
+
+// candidates lists the matching routes of registered providers, best first
+func (c *MarketClient) candidates(inst domain.Instrument) []Route {
+	var routes []Route
+	for _, r := range c.routes {
+		if _, ok := c.providers[r.Provider]; ok && r.matches(inst) {
+			routes = append(routes, r)
+		}
+	}
+	for _, name := range c.order {
+		if ports.CapabilitiesOf(c.providers[name]).Coverage.Covers(inst) {
+			routes = append(routes, Route{AssetType: inst.AssetType, Provider: name})
+		}
+	}
+
+	rank := make(map[string]int, len(c.order))
+	for i, name := range c.order {
+		rank[name] = i
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.specificity() != b.specificity() {
+			return a.specificity() > b.specificity()
+		}
+		return rank[a.Provider] < rank[b.Provider]
+	})
+	return routes
+}
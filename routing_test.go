@@ -0,0 +1,94 @@
+package markets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"markets-sdk"
+	"markets-sdk/pkg/decorators"
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// coveredProvider declares its coverage and prices every symbol at price
+type coveredProvider struct {
+	mockProvider
+	coverage domain.Coverage
+}
+
+func (p *coveredProvider) Capabilities() ports.Capabilities {
+	return ports.Capabilities{Coverage: p.coverage}
+}
+
+func TestMarketClientQuoteRouting(t *testing.T) {
+	crypto := &coveredProvider{mockProvider{price: 1}, domain.Coverage{AssetTypes: []domain.AssetType{domain.AssetTypeCrypto}}}
+	stocks := &coveredProvider{mockProvider{price: 2}, domain.Coverage{
+		AssetTypes: []domain.AssetType{domain.AssetTypeStock, domain.AssetTypeCrypto},
+		Exchanges:  []string{"XNAS"},
+	}}
+	london := &mockProvider{price: 3}
+
+	client := markets.NewMarketClient(markets.WithRoutes(markets.Route{Exchange: "XLON", Provider: "london"}))
+	client.RegisterProvider("crypto", crypto, decorators.NewBuilder().Retry(1, 0).Middleware())
+	client.RegisterProvider("stocks", stocks)
+	client.RegisterProvider("london", london)
+	ctx := context.Background()
+
+	quote := func(s string) (*domain.Quote, error) {
+		return client.Quote(ctx, domain.MustParseInstrument(s))
+	}
+
+	// 1. Coverage routes by asset type, also through middleware; ties go to the
+	// provider registered first
+	if q, err := quote("crypto:BTC/USD"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(1)) {
+		t.Errorf("expected crypto provider, got %+v, %v", q, err)
+	}
+	if q, err := quote("stock:XNAS:AAPL"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(2)) {
+		t.Errorf("expected stocks provider, got %+v, %v", q, err)
+	}
+
+	// 2. Explicit routes cover what providers do not declare
+	if q, err := quote("stock:XLON:VOD"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(3)) || q.Symbol != "VOD" {
+		t.Errorf("expected london provider with native symbol VOD, got %+v, %v", q, err)
+	}
+
+	// 3. Priorities override coverage
+	client.AddRoute(markets.Route{AssetType: domain.AssetTypeCrypto, Provider: "stocks", Priority: 10})
+	if q, err := quote("crypto:ETH"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(2)) {
+		t.Errorf("expected prioritised stocks provider, got %+v, %v", q, err)
+	}
+
+	// 4. Nothing routes an unsupported exchange
+	if _, err := quote("stock:XTKS:7203"); !errors.Is(err, domain.ErrProviderNotFound) {
+		t.Errorf("expected ErrProviderNotFound, got %v", err)
+	}
+
+	// The explicit API still reaches any provider
+	if q, err := client.GetQuote(ctx, "london", "BTC"); err != nil || !q.Price.Equal(domain.NewDecimalFromInt(3)) {
+		t.Errorf("expected explicit provider, got %+v, %v", q, err)
+	}
+}
+
+func TestMarketClientResolveSkipsUntranslatable(t *testing.T) {
+	client := markets.NewMarketClient(markets.WithRoutes(
+		markets.Route{Provider: "picky", Priority: 1},
+		markets.Route{Provider: "plain"},
+	))
+	client.RegisterProvider("picky", &pickyProvider{})
+	client.RegisterProvider("plain", &mockProvider{})
+
+	name, symbol, err := client.Resolve(domain.MustParseInstrument("stock:AAPL"))
+	if err != nil || name != "plain" || symbol != "AAPL" {
+		t.Errorf("expected plain/AAPL, got %s/%s, %v", name, symbol, err)
+	}
+}
+
+// pickyProvider cannot translate any instrument
+type pickyProvider struct {
+	mockProvider
+}
+
+func (p *pickyProvider) NativeSymbol(inst domain.Instrument) (string, error) {
+	return "", domain.ErrNotSupported
+}
@@ -0,0 +1,403 @@
+package markets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"markets-sdk/pkg/domain"
+	"markets-sdk/pkg/ports"
+)
+
+// SlowConsumerPolicy decides what happens when a subscriber does not keep up
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerConflate keeps only the latest undelivered quote per symbol
+	SlowConsumerConflate SlowConsumerPolicy = iota
+	// SlowConsumerDrop discards new quotes while the subscriber's buffer is full
+	SlowConsumerDrop
+	// SlowConsumerBlock waits for the subscriber, delaying every other subscriber of the symbol
+	SlowConsumerBlock
+)
+
+const (
+	defaultStreamBuffer     = 64
+	defaultReconnectBackoff = 500 * time.Millisecond
+	defaultMaxReconnect     = 30 * time.Second
+	unsubscribeTimeout      = 5 * time.Second
+)
+
+// WithReconnectBackoff sets the exponential backoff used to re-establish dropped upstream subscriptions
+func WithReconnectBackoff(base, max time.Duration) ClientOption {
+	return func(c *MarketClient) {
+		if base > 0 {
+			c.reconnectBase = base
+		}
+		if max >= base {
+			c.reconnectMax = max
+		}
+	}
+}
+
+// SubscribeOption configures a single subscription
+type SubscribeOption func(*consumer)
+
+// WithSlowConsumerPolicy sets how quotes are handled when the subscriber falls behind.
+// Defaults to SlowConsumerConflate.
+func WithSlowConsumerPolicy(policy SlowConsumerPolicy) SubscribeOption {
+	return func(cs *consumer) {
+		cs.policy = policy
+	}
+}
+
+// WithStreamErrorHandler sets a function called when the upstream subscription of one of
+// the symbols fails for good, for instance because the provider does not know the symbol.
+// The symbol is dropped from the subscription, and the channel is closed once no symbol is left.
+func WithStreamErrorHandler(fn func(symbol string, err error)) SubscribeOption {
+	return func(cs *consumer) {
+		cs.onError = fn
+	}
+}
+
+// WithBufferSize sets how many quotes are queued for the subscriber under the drop and block policies
+func WithBufferSize(n int) SubscribeOption {
+	return func(cs *consumer) {
+		if n > 0 {
+			cs.bufferSize = n
+		}
+	}
+}
+
+type streamKey struct {
+	provider string
+	symbol   string
+}
+
+// stream is a single upstream subscription shared by every consumer of a symbol
+type stream struct {
+	cancel  context.CancelFunc
+	done    chan struct{} // closed once the upstream subscription is torn down
+	closing bool          // guarded by MarketClient.streamMu
+
+	mu        sync.Mutex
+	consumers map[*consumer]struct{}
+}
+
+// consumer is one Subscribe caller. A pump goroutine owns the output channel and
+// drains quotes queued by the streams according to the consumer's policy.
+type consumer struct {
+	policy     SlowConsumerPolicy
+	bufferSize int
+	onError    func(symbol string, err error)
+
+	out    chan domain.Quote
+	in     chan domain.Quote
+	done   chan struct{}
+	notify chan struct{}
+	stop   sync.Once
+
+	mu      sync.Mutex
+	pending map[string]domain.Quote // conflated quotes by symbol
+	order   []string
+	streams int // streams the consumer still listens to
+}
+
+// Subscribe streams quotes for symbols from a provider implementing ports.StreamingProvider.
+// Consumers of the same provider and symbol share one upstream subscription, which is
+// re-established with backoff if it drops. A symbol whose subscription fails for good,
+// with domain.ErrSymbolNotFound, domain.ErrNotSupported or domain.ErrUnauthorized, is
+// dropped and reported to the WithStreamErrorHandler function. The channel is closed when
+// ctx ends or when every symbol has been dropped.
+func (c *MarketClient) Subscribe(ctx context.Context, providerName string, symbols []string, opts ...SubscribeOption) (<-chan domain.Quote, error) {
+	p, ok := c.providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrProviderNotFound, providerName)
+	}
+	sp, ok := ports.AsStreamingProvider(p)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support streaming", domain.ErrNotSupported, providerName)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols to subscribe")
+	}
+
+	cons := &consumer{
+		policy:     SlowConsumerConflate,
+		bufferSize: defaultStreamBuffer,
+		done:       make(chan struct{}),
+		notify:     make(chan struct{}, 1),
+		pending:    make(map[string]domain.Quote),
+	}
+	for _, opt := range opts {
+		opt(cons)
+	}
+	cons.out = make(chan domain.Quote)
+	cons.in = make(chan domain.Quote, cons.bufferSize)
+
+	symbols = dedupe(symbols)
+	cons.streams = len(symbols)
+	for _, symbol := range symbols {
+		c.join(sp, streamKey{provider: providerName, symbol: symbol}, cons)
+	}
+
+	go cons.pump()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-cons.done:
+		}
+		cons.close()
+		for _, symbol := range symbols {
+			c.leave(streamKey{provider: providerName, symbol: symbol}, cons)
+		}
+	}()
+
+	return cons.out, nil
+}
+
+// join attaches cons to the stream for key, starting the upstream subscription if needed.
+// A stream still being torn down is replaced by one that subscribes once it is gone.
+func (c *MarketClient) join(sp ports.StreamingProvider, key streamKey, cons *consumer) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	s, ok := c.streams[key]
+	if !ok || s.closing {
+		var prev <-chan struct{}
+		if ok {
+			prev = s.done
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s = &stream{cancel: cancel, done: make(chan struct{}), consumers: make(map[*consumer]struct{})}
+		c.streams[key] = s
+		go c.runStream(ctx, sp, key, s, prev)
+	}
+
+	s.mu.Lock()
+	s.consumers[cons] = struct{}{}
+	s.mu.Unlock()
+}
+
+// leave detaches cons and tears the upstream subscription down once nobody listens
+func (c *MarketClient) leave(key streamKey, cons *consumer) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	s, ok := c.streams[key]
+	if !ok || s.closing {
+		return
+	}
+
+	s.mu.Lock()
+	_, joined := s.consumers[cons]
+	delete(s.consumers, cons)
+	empty := len(s.consumers) == 0
+	s.mu.Unlock()
+
+	// The stream stays registered until runStream has unsubscribed, so that a new
+	// consumer of the symbol waits for the teardown instead of racing it
+	if joined && empty {
+		s.closing = true
+		s.cancel()
+	}
+}
+
+// runStream keeps an upstream subscription alive until ctx is cancelled. It starts once
+// the previous subscription to the symbol, if any, is torn down, so that unsubscribing
+// the old one cannot end the new one.
+func (c *MarketClient) runStream(ctx context.Context, sp ports.StreamingProvider, key streamKey, s *stream, prev <-chan struct{}) {
+	defer close(s.done)
+	defer c.forget(key, s)
+	if prev != nil {
+		<-prev
+	}
+
+	backoff := c.reconnectBase
+	for {
+		ch, err := sp.Subscribe(ctx, []string{key.symbol})
+		if err == nil {
+		recv:
+			for {
+				select {
+				case q, ok := <-ch:
+					if !ok {
+						break recv
+					}
+					backoff = c.reconnectBase
+					s.broadcast(q)
+				case <-ctx.Done():
+					unsubscribe(sp, key.symbol)
+					return
+				}
+			}
+		} else if permanent(err) {
+			c.fail(key, s, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			unsubscribe(sp, key.symbol)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > c.reconnectMax {
+			backoff = c.reconnectMax
+		}
+	}
+}
+
+// permanent reports whether retrying a failed Subscribe cannot succeed
+func permanent(err error) bool {
+	return errors.Is(err, domain.ErrSymbolNotFound) ||
+		errors.Is(err, domain.ErrNotSupported) ||
+		errors.Is(err, domain.ErrUnauthorized)
+}
+
+func unsubscribe(sp ports.StreamingProvider, symbol string) {
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	_ = sp.Unsubscribe(ctx, []string{symbol})
+}
+
+// forget unregisters s once its upstream subscription is torn down
+func (c *MarketClient) forget(key streamKey, s *stream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	if c.streams[key] == s {
+		delete(c.streams, key)
+	}
+}
+
+// fail closes s after a permanent error and reports it to its consumers
+func (c *MarketClient) fail(key streamKey, s *stream, err error) {
+	c.streamMu.Lock()
+	s.closing = true
+	c.streamMu.Unlock()
+	s.cancel()
+
+	s.mu.Lock()
+	consumers := make([]*consumer, 0, len(s.consumers))
+	for cons := range s.consumers {
+		consumers = append(consumers, cons)
+	}
+	s.consumers = nil
+	s.mu.Unlock()
+
+	for _, cons := range consumers {
+		cons.drop(key.symbol, err)
+	}
+}
+
+func (s *stream) broadcast(q domain.Quote) {
+	s.mu.Lock()
+	consumers := make([]*consumer, 0, len(s.consumers))
+	for cons := range s.consumers {
+		consumers = append(consumers, cons)
+	}
+	s.mu.Unlock()
+
+	for _, cons := range consumers {
+		cons.deliver(q)
+	}
+}
+
+// deliver queues q for the consumer according to its slow consumer policy
+func (cs *consumer) deliver(q domain.Quote) {
+	switch cs.policy {
+	case SlowConsumerDrop:
+		select {
+		case cs.in <- q:
+		default:
+		}
+	case SlowConsumerBlock:
+		select {
+		case cs.in <- q:
+		case <-cs.done:
+		}
+	default:
+		cs.mu.Lock()
+		if _, ok := cs.pending[q.Symbol]; !ok {
+			cs.order = append(cs.order, q.Symbol)
+		}
+		cs.pending[q.Symbol] = q
+		cs.mu.Unlock()
+
+		select {
+		case cs.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// drop removes a failed symbol from the consumer and closes it once none is left
+func (cs *consumer) drop(symbol string, err error) {
+	if cs.onError != nil {
+		cs.onError(symbol, err)
+	}
+	cs.mu.Lock()
+	cs.streams--
+	last := cs.streams == 0
+	cs.mu.Unlock()
+	if last {
+		cs.close()
+	}
+}
+
+func (cs *consumer) close() {
+	cs.stop.Do(func() { close(cs.done) })
+}
+
+// pump forwards queued quotes to the subscriber and closes its channel when done
+func (cs *consumer) pump() {
+	defer close(cs.out)
+
+	for {
+		select {
+		case <-cs.done:
+			return
+		case q := <-cs.in:
+			if !cs.send(q) {
+				return
+			}
+		case <-cs.notify:
+			for {
+				q, ok := cs.next()
+				if !ok {
+					break
+				}
+				if !cs.send(q) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// next pops the oldest conflated quote
+func (cs *consumer) next() (domain.Quote, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if len(cs.order) == 0 {
+		return domain.Quote{}, false
+	}
+	symbol := cs.order[0]
+	cs.order = cs.order[1:]
+	q := cs.pending[symbol]
+	delete(cs.pending, symbol)
+	return q, true
+}
+
+func (cs *consumer) send(q domain.Quote) bool {
+	select {
+	case cs.out <- q:
+		return true
+	case <-cs.done:
+		return false
+	}
+}
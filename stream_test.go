@@ -0,0 +1,284 @@
+package markets_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"markets-sdk"
+	"markets-sdk/pkg/domain"
+)
+
+// mockStreamingProvider hands out one feed per Subscribe call
+type mockStreamingProvider struct {
+	mockProvider
+
+	errs       map[string]error // Subscribe errors by symbol
+	unsubDelay time.Duration
+
+	mu           sync.Mutex
+	feeds        []chan domain.Quote
+	attempts     int
+	leaving      int // Unsubscribe calls started
+	unsubscribed []string
+	events       []string
+}
+
+func (m *mockStreamingProvider) Subscribe(ctx context.Context, symbols []string) (<-chan domain.Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.attempts++
+	if err := m.errs[symbols[0]]; err != nil {
+		return nil, err
+	}
+	feed := make(chan domain.Quote, 16)
+	m.feeds = append(m.feeds, feed)
+	m.events = append(m.events, "subscribe")
+	return feed, nil
+}
+
+func (m *mockStreamingProvider) Unsubscribe(ctx context.Context, symbols []string) error {
+	m.mu.Lock()
+	m.leaving++
+	m.mu.Unlock()
+
+	time.Sleep(m.unsubDelay)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unsubscribed = append(m.unsubscribed, symbols...)
+	m.events = append(m.events, "unsubscribe")
+	return nil
+}
+
+func (m *mockStreamingProvider) feed(i int) chan domain.Quote {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i >= len(m.feeds) {
+		return nil
+	}
+	return m.feeds[i]
+}
+
+func (m *mockStreamingProvider) subscriptions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.feeds)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, ch <-chan domain.Quote) domain.Quote {
+	t.Helper()
+	select {
+	case q := <-ch:
+		return q
+	case <-time.After(time.Second):
+		t.Fatal("no quote received")
+	}
+	return domain.Quote{}
+}
+
+func TestMarketClientSubscribe(t *testing.T) {
+	sp := &mockStreamingProvider{}
+	client := markets.NewMarketClient(markets.WithReconnectBackoff(time.Millisecond, 5*time.Millisecond))
+	client.RegisterProvider("stream", sp)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	// 1. Two consumers share one upstream subscription
+	ch1, err := client.Subscribe(ctx1, "stream", []string{"BTC"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ch2, err := client.Subscribe(ctx2, "stream", []string{"BTC"}, markets.WithSlowConsumerPolicy(markets.SlowConsumerDrop))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return sp.feed(0) != nil })
+	sp.feed(0) <- domain.Quote{Symbol: "BTC", Price: domain.NewDecimalFromInt(100)}
+
+	if q := receive(t, ch1); !q.Price.Equal(domain.NewDecimalFromInt(100)) {
+		t.Errorf("expected price 100, got %v", q.Price)
+	}
+	if q := receive(t, ch2); !q.Price.Equal(domain.NewDecimalFromInt(100)) {
+		t.Errorf("expected price 100, got %v", q.Price)
+	}
+	if n := sp.subscriptions(); n != 1 {
+		t.Errorf("expected 1 upstream subscription, got %d", n)
+	}
+
+	// 2. Upstream drop triggers a reconnect
+	close(sp.feed(0))
+	waitFor(t, func() bool { return sp.feed(1) != nil })
+	sp.feed(1) <- domain.Quote{Symbol: "BTC", Price: domain.NewDecimalFromInt(101)}
+	if q := receive(t, ch1); !q.Price.Equal(domain.NewDecimalFromInt(101)) {
+		t.Errorf("expected price 101 after reconnect, got %v", q.Price)
+	}
+
+	// 3. Leaving consumers close their channel; the last one unsubscribes upstream
+	cancel1()
+	waitFor(t, func() bool {
+		select {
+		case _, ok := <-ch1:
+			return !ok
+		default:
+			return false
+		}
+	})
+	cancel2()
+	waitFor(t, func() bool {
+		sp.mu.Lock()
+		defer sp.mu.Unlock()
+		return len(sp.unsubscribed) == 1
+	})
+
+	// 4. Non streaming provider
+	client.RegisterProvider("plain", &mockProvider{})
+	if _, err := client.Subscribe(context.Background(), "plain", []string{"BTC"}); err == nil {
+		t.Error("expected error for provider without streaming support")
+	}
+}
+
+func TestMarketClientSubscribeConflate(t *testing.T) {
+	sp := &mockStreamingProvider{}
+	client := markets.NewMarketClient()
+	client.RegisterProvider("stream", sp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := client.Subscribe(ctx, "stream", []string{"BTC"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, func() bool { return sp.feed(0) != nil })
+
+	// The consumer is not reading, so intermediate quotes collapse into the latest one
+	for i := 1; i <= 10; i++ {
+		sp.feed(0) <- domain.Quote{Symbol: "BTC", Price: domain.NewDecimalFromInt(int64(i))}
+	}
+	waitFor(t, func() bool { return len(sp.feed(0)) == 0 })
+	time.Sleep(10 * time.Millisecond)
+
+	// The pump may already hold one early quote; everything after it collapses into 10
+	ten := domain.NewDecimalFromInt(10)
+	var got []domain.Decimal
+	for len(got) == 0 || !got[len(got)-1].Equal(ten) {
+		if len(got) == 2 {
+			t.Fatalf("expected at most one quote before the conflated one, got %v", got)
+		}
+		got = append(got, receive(t, ch).Price)
+	}
+	select {
+	case q := <-ch:
+		t.Errorf("expected nothing after the conflated quote, got %v", q.Price)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestMarketClientResubscribeAfterTeardown(t *testing.T) {
+	sp := &mockStreamingProvider{unsubDelay: 20 * time.Millisecond}
+	client := markets.NewMarketClient()
+	client.RegisterProvider("stream", sp)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	if _, err := client.Subscribe(ctx1, "stream", []string{"BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, func() bool { return sp.feed(0) != nil })
+
+	// A new consumer arriving while the old subscription is torn down subscribes after it
+	cancel1()
+	waitFor(t, func() bool {
+		sp.mu.Lock()
+		defer sp.mu.Unlock()
+		return sp.leaving == 1
+	})
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch, err := client.Subscribe(ctx2, "stream", []string{"BTC"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, func() bool { return sp.feed(1) != nil })
+
+	sp.mu.Lock()
+	events := slices.Clone(sp.events)
+	sp.mu.Unlock()
+	if !slices.Equal(events, []string{"subscribe", "unsubscribe", "subscribe"}) {
+		t.Errorf("expected the old subscription torn down before the new one, got %v", events)
+	}
+
+	sp.feed(1) <- domain.Quote{Symbol: "BTC", Price: domain.NewDecimalFromInt(1)}
+	receive(t, ch)
+}
+
+func TestMarketClientSubscribePermanentError(t *testing.T) {
+	sp := &mockStreamingProvider{errs: map[string]error{"NOPE": domain.ErrSymbolNotFound}}
+	client := markets.NewMarketClient(markets.WithReconnectBackoff(time.Millisecond, time.Millisecond))
+	client.RegisterProvider("stream", sp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failed := make(chan string, 2)
+	onError := markets.WithStreamErrorHandler(func(symbol string, err error) {
+		if !errors.Is(err, domain.ErrSymbolNotFound) {
+			t.Errorf("expected ErrSymbolNotFound, got %v", err)
+		}
+		failed <- symbol
+	})
+
+	// The unknown symbol is reported and dropped, the other keeps streaming
+	ch, err := client.Subscribe(ctx, "stream", []string{"BTC", "NOPE"}, onError)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case symbol := <-failed:
+		if symbol != "NOPE" {
+			t.Errorf("expected NOPE to fail, got %s", symbol)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("error not reported")
+	}
+	waitFor(t, func() bool { return sp.feed(0) != nil })
+	sp.feed(0) <- domain.Quote{Symbol: "BTC", Price: domain.NewDecimalFromInt(1)}
+	receive(t, ch)
+
+	// Once every symbol is dropped the channel closes, without retrying
+	only, err := client.Subscribe(ctx, "stream", []string{"NOPE"}, onError)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case _, ok := <-only:
+		if ok {
+			t.Error("expected the channel to close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+	time.Sleep(10 * time.Millisecond)
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.attempts != 3 {
+		t.Errorf("expected no retries of a permanent error, got %d attempts", sp.attempts)
+	}
+}